@@ -12,22 +12,23 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
 )
 
-// ConfigAWS creates AWS config. If profile is provided it is used as a aws profile name
-func ConfigAWS(profile string, sessionDuration time.Duration) *aws.Config {
-	var cfg aws.Config
-	var err error
+// ConfigAWS creates AWS config. If profile is provided it is used as a aws profile name.
+// If region is provided it overrides the region from the profile or the environment
+func ConfigAWS(profile string, region string, sessionDuration time.Duration) *aws.Config {
+	var opts []func(*config.LoadOptions) error
 	if profile != "" {
-		cfg, err = config.LoadDefaultConfig(context.TODO(),
+		opts = append(opts,
 			config.WithSharedConfigProfile(profile),
 			config.WithAssumeRoleCredentialOptions(func(o *stscreds.AssumeRoleOptions) {
 				o.TokenProvider = stscreds.StdinTokenProvider
 				o.Duration = sessionDuration
 			}))
-	} else {
-		cfg, err = config.LoadDefaultConfig(context.TODO())
-
+	}
+	if region != "" {
+		opts = append(opts, config.WithRegion(region))
 	}
 
+	cfg, err := config.LoadDefaultConfig(context.TODO(), opts...)
 	if err != nil {
 		log.Fatalf("Failed to load AWS config: %v", err)
 	}
@@ -0,0 +1,228 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// PathEnvVariable is the name of environment variable that overrides location of the configuration file
+	PathEnvVariable = "CWLTAIL_CONFIG"
+	// GroupsKey is the configuration key for the list of log groups
+	GroupsKey = "groups"
+)
+
+// Config contains option values loaded from the configuration file. Keys are long names of command line options
+type Config struct {
+	Options map[string]interface{}            `yaml:",inline"`
+	Presets map[string]map[string]interface{} `yaml:"presets"`
+}
+
+// DefaultPath returns location of the configuration file, which is $CWLTAIL_CONFIG if set,
+// or cwltail/config.yaml in the user configuration directory
+func DefaultPath() string {
+	if path := os.Getenv(PathEnvVariable); path != "" {
+		return path
+	}
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "cwltail", "config.yaml")
+}
+
+// Load reads configuration file from path. Missing file is not an error, empty configuration is returned instead
+func Load(path string) (*Config, error) {
+	cfg := &Config{}
+	if path == "" {
+		return cfg, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+	return cfg, nil
+}
+
+// Preset returns option values of the named preset merged over the top-level option values
+func (c *Config) Preset(name string) (map[string]interface{}, error) {
+	values := make(map[string]interface{}, len(c.Options))
+	for k, v := range c.Options {
+		values[k] = v
+	}
+	if name == "" {
+		return values, nil
+	}
+	preset, ok := c.Presets[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown preset '%s'", name)
+	}
+	for k, v := range preset {
+		values[k] = v
+	}
+	return values, nil
+}
+
+// option is a field of the options struct
+type option struct {
+	value      reflect.Value
+	positional bool
+}
+
+// optionNames returns long and short option names of the struct field from its go-arg tag.
+// Positional slice is named GroupsKey, positional arguments have no short names
+func optionNames(field reflect.StructField) (string, string, bool) {
+	tag, ok := field.Tag.Lookup("arg")
+	if !ok {
+		return strings.ToLower(field.Name), "", false
+	}
+	long, short, positional := "", "", false
+	for _, part := range strings.Split(tag, ",") {
+		switch {
+		case strings.HasPrefix(part, "--"):
+			long = part[2:]
+		case strings.HasPrefix(part, "-"):
+			short = part[1:]
+		case part == "positional":
+			positional = true
+		}
+	}
+	if positional && field.Type.Kind() == reflect.Slice {
+		return GroupsKey, "", true
+	}
+	if long == "" {
+		long = strings.ToLower(field.Name)
+	}
+	return long, short, positional
+}
+
+// optionFields collects fields of the struct by their long option names, and long names by short ones
+func optionFields(v reflect.Value, fields map[string]option, longNames map[string]string) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			optionFields(v.Field(i), fields, longNames)
+			continue
+		}
+		long, short, positional := optionNames(field)
+		fields[long] = option{value: v.Field(i), positional: positional}
+		if short != "" {
+			longNames[short] = long
+		}
+	}
+}
+
+// passedOptions returns long names of the options present in the command line arguments
+func passedOptions(args []string, longNames map[string]string) map[string]bool {
+	passed := make(map[string]bool)
+	for _, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		name := strings.TrimLeft(arg, "-")
+		if i := strings.Index(name, "="); i >= 0 {
+			name = name[:i]
+		}
+		if !strings.HasPrefix(arg, "--") {
+			name = longNames[name]
+		}
+		passed[name] = true
+	}
+	return passed
+}
+
+func setValue(field reflect.Value, value interface{}) error {
+	switch field.Kind() {
+	case reflect.Ptr:
+		// pointer options distinguish values which were set from zero values
+		elem := reflect.New(field.Type().Elem())
+		if err := setValue(elem.Elem(), value); err != nil {
+			return err
+		}
+		field.Set(elem)
+	case reflect.String:
+		field.SetString(fmt.Sprint(value))
+	case reflect.Bool:
+		b, ok := value.(bool)
+		if !ok {
+			return fmt.Errorf("expected true or false, got '%v'", value)
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(fmt.Sprint(value), 10, 64)
+		if err != nil {
+			return fmt.Errorf("expected integer, got '%v'", value)
+		}
+		field.SetInt(i)
+	case reflect.Slice:
+		var items []string
+		if list, ok := value.([]interface{}); ok {
+			for _, item := range list {
+				items = append(items, fmt.Sprint(item))
+			}
+		} else {
+			items = []string{fmt.Sprint(value)}
+		}
+		field.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported option type %v", field.Type())
+	}
+	return nil
+}
+
+// Apply sets fields of dest from values, unless the options were passed in the command line arguments.
+// It is called after the command line is parsed, so the values override default values of the options,
+// even if the values are zero. dest must be a pointer to a struct with go-arg tags.
+// If strict is true then keys without matching fields are reported as errors, otherwise they are ignored
+func Apply(dest interface{}, values map[string]interface{}, args []string, strict bool) error {
+	fields := make(map[string]option)
+	longNames := make(map[string]string)
+	optionFields(reflect.ValueOf(dest).Elem(), fields, longNames)
+	passed := passedOptions(args, longNames)
+
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		field, ok := fields[key]
+		if !ok {
+			if strict {
+				return fmt.Errorf("unknown option '%s'", key)
+			}
+			continue
+		}
+		// positional arguments are not named, they are passed if they are set
+		if passed[key] || (field.positional && !field.value.IsZero()) {
+			continue
+		}
+		if err := setValue(field.value, values[key]); err != nil {
+			return fmt.Errorf("invalid value of '%s': %v", key, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+type embedded struct {
+	Profile string `arg:"-p,--profile"`
+}
+
+type testOptions struct {
+	embedded
+	Pattern   string   `arg:"-c,--color-pattern"`
+	Highlight bool     `arg:"-w,--level-highlight"`
+	Context   int      `arg:"-C,--context"`
+	After     *int     `arg:"-A,--after-context"`
+	Frames    int      `arg:"--trace-frames" default:"3"`
+	Exclude   []string `arg:"-x,--exclude,separate"`
+	LogGroups []string `arg:"positional"`
+}
+
+const testConfig = `
+profile: dev
+color-pattern: (\d+)
+presets:
+  prod-api:
+    groups: [/aws/lambda/api, /aws/lambda/worker]
+    profile: prod
+    level-highlight: true
+    context: 3
+    exclude: health
+`
+
+func loadTestConfig(t *testing.T) *Config {
+	cfg := &Config{}
+	if err := yaml.Unmarshal([]byte(testConfig), cfg); err != nil {
+		t.Fatalf("Failed to parse config: %v", err)
+	}
+	return cfg
+}
+
+func TestApplyTopLevelOptions(t *testing.T) {
+	cfg := loadTestConfig(t)
+	values, err := cfg.Preset("")
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	var opts testOptions
+	if err = Apply(&opts, values, nil, true); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if opts.Profile != "dev" || opts.Pattern != `(\d+)` || opts.Highlight {
+		t.Errorf("Unexpected options %+v", opts)
+	}
+}
+
+func TestApplyPreset(t *testing.T) {
+	cfg := loadTestConfig(t)
+	values, err := cfg.Preset("prod-api")
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	opts := testOptions{Exclude: []string{"from-command-line"}}
+	if err = Apply(&opts, values, []string{"-x", "from-command-line"}, true); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if opts.Profile != "prod" || opts.Pattern != `(\d+)` || !opts.Highlight || opts.Context != 3 {
+		t.Errorf("Unexpected options %+v", opts)
+	}
+	expectedGroups := []string{"/aws/lambda/api", "/aws/lambda/worker"}
+	if !reflect.DeepEqual(opts.LogGroups, expectedGroups) {
+		t.Errorf("\nExpected: %v\n  Actual: %v", expectedGroups, opts.LogGroups)
+	}
+	if !reflect.DeepEqual(opts.Exclude, []string{"from-command-line"}) {
+		t.Errorf("Command line value must not be overridden, got %v", opts.Exclude)
+	}
+}
+
+func TestCommandLineOverridesConfig(t *testing.T) {
+	values := map[string]interface{}{"profile": "dev", "context": 3, "color-pattern": "x", GroupsKey: []interface{}{"/a"}}
+	opts := testOptions{LogGroups: []string{"/b"}}
+	opts.Profile = "prod"
+	opts.Context = 1
+	if err := Apply(&opts, values, []string{"--profile=prod", "-C", "1", "/b", "--", "--color-pattern"}, true); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if opts.Profile != "prod" || opts.Context != 1 || opts.Pattern != "x" || !reflect.DeepEqual(opts.LogGroups, []string{"/b"}) {
+		t.Errorf("Unexpected options %+v", opts)
+	}
+}
+
+func TestUnknownPreset(t *testing.T) {
+	cfg := loadTestConfig(t)
+	if _, err := cfg.Preset("staging"); err == nil {
+		t.Errorf("Expected error for unknown preset")
+	}
+}
+
+func TestUnknownOption(t *testing.T) {
+	var opts testOptions
+	err := Apply(&opts, map[string]interface{}{"colour-pattern": "x"}, nil, true)
+	if err == nil {
+		t.Errorf("Expected error for unknown option")
+	}
+	err = Apply(&opts, map[string]interface{}{"colour-pattern": "x"}, nil, false)
+	if err != nil {
+		t.Errorf("Unexpected error in non-strict mode %v", err)
+	}
+}
+
+func TestApplyZeroValue(t *testing.T) {
+	values := map[string]interface{}{"after-context": 0, "trace-frames": 0}
+	// options are applied after the command line is parsed, so they hold go-arg defaults
+	opts := testOptions{Frames: 3}
+	if err := Apply(&opts, values, nil, true); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if opts.After == nil || *opts.After != 0 || opts.Frames != 0 {
+		t.Errorf("Expected explicit zeroes, got %v %d", opts.After, opts.Frames)
+	}
+
+	fromCommandLine := 2
+	opts.After = &fromCommandLine
+	if err := Apply(&opts, values, []string{"-A", "2"}, true); err != nil || *opts.After != 2 {
+		t.Errorf("Command line value must not be overridden, got %v %v", *opts.After, err)
+	}
+}
+
+func TestInvalidValue(t *testing.T) {
+	var opts testOptions
+	err := Apply(&opts, map[string]interface{}{"level-highlight": "yes please"}, nil, true)
+	if err == nil {
+		t.Errorf("Expected error for invalid boolean")
+	}
+}
+
+func TestMissingFile(t *testing.T) {
+	cfg, err := Load("/nonexistent/cwltail/config.yaml")
+	if err != nil || cfg == nil {
+		t.Errorf("Missing file must result in empty configuration, got %v", err)
+	}
+}
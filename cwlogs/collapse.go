@@ -0,0 +1,156 @@
+package cwlogs
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+)
+
+// Scopes of collapsing repeated messages
+const (
+	CollapseStream = "stream"
+	CollapseGlobal = "global"
+)
+
+// DefaultCollapseFlush is the time after which the count of repeated messages is reported, even if
+// the messages still keep repeating
+const DefaultCollapseFlush = 10 * time.Second
+
+// variableTokens matches UUIDs, hexadecimal numbers and identifiers, and decimal numbers
+var variableTokens = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}|0x[0-9a-fA-F]+|\b[0-9a-fA-F]{16,}\b|\d+`)
+
+// MaskVariableTokens replaces numbers and UUIDs in the message with #, so messages which differ only
+// in these tokens are equal after masking
+func MaskVariableTokens(message string) string {
+	return variableTokens.ReplaceAllString(message, "#")
+}
+
+// RepeatNote is the event reporting that the message of the event was repeated. Timestamp is the time
+// of the last repetition
+type RepeatNote struct {
+	CWLEvent
+	Count int
+	// Similar is true if some of the repeated messages differed from the displayed one in numbers or UUIDs
+	Similar bool
+	Last    time.Time
+}
+
+// Timestamp returns the time of the last repetition
+func (n *RepeatNote) Timestamp() time.Time {
+	return n.Last
+}
+
+// Message returns the syslog-like description of the repetition
+func (n *RepeatNote) Message() string {
+	if n.Similar {
+		return fmt.Sprintf("similar messages repeated %d times", n.Count)
+	}
+	return fmt.Sprintf("last message repeated %d times", n.Count)
+}
+
+type repeatRun struct {
+	event   CWLEvent
+	masked  string
+	count   int
+	similar bool
+	last    time.Time
+	// reported is the time when the run started or its repetitions were last reported
+	reported time.Time
+}
+
+func (r *repeatRun) note(now time.Time) CWLEvent {
+	note := &RepeatNote{CWLEvent: r.event, Count: r.count, Similar: r.similar, Last: r.last}
+	r.count = 0
+	r.similar = false
+	r.reported = now
+	return note
+}
+
+// Collapser suppresses consecutive repeated messages of each stream, or of all the streams, and replaces
+// them with a single RepeatNote
+type Collapser struct {
+	global bool
+	flush  time.Duration
+	runs   map[string]*repeatRun
+}
+
+// NewCollapser creates a collapser. If global is true then messages are compared with the previous message
+// of any stream, otherwise with the previous message of the same stream. Repetitions are reported when
+// a different message arrives or after flush time
+func NewCollapser(global bool, flush time.Duration) *Collapser {
+	return &Collapser{
+		global: global,
+		flush:  flush,
+		runs:   make(map[string]*repeatRun),
+	}
+}
+
+func (c *Collapser) key(event CWLEvent) string {
+	if c.global {
+		return ""
+	}
+	return event.LogGroup() + "/" + event.LogStream()
+}
+
+// Add adds the event received at now and returns events to display
+func (c *Collapser) Add(event CWLEvent, now time.Time) []CWLEvent {
+	key := c.key(event)
+	masked := MaskVariableTokens(event.Message())
+	run, ok := c.runs[key]
+	if ok && run.masked == masked {
+		run.count++
+		run.similar = run.similar || event.Message() != run.event.Message()
+		run.last = event.Timestamp()
+		return nil
+	}
+	var result []CWLEvent
+	if ok && run.count > 0 {
+		result = append(result, run.note(now))
+	}
+	c.runs[key] = &repeatRun{event: event, masked: masked, last: event.Timestamp(), reported: now}
+	return append(result, event)
+}
+
+// Flush returns notes of the repetitions which were not reported for longer than the flush time,
+// or of all repetitions if all is true
+func (c *Collapser) Flush(now time.Time, all bool) []CWLEvent {
+	keys := make([]string, 0, len(c.runs))
+	for key := range c.runs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var result []CWLEvent
+	for _, key := range keys {
+		run := c.runs[key]
+		if run.count > 0 && (all || now.Sub(run.reported) > c.flush) {
+			result = append(result, run.note(now))
+		}
+	}
+	return result
+}
+
+// Collapse reads events from in, collapses repetitions and writes the events to out. out is closed when in is closed
+func (c *Collapser) Collapse(in <-chan CWLEvent, out chan<- CWLEvent) {
+	ticker := time.NewTicker(c.flush / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case event, ok := <-in:
+			if !ok {
+				for _, e := range c.Flush(time.Now(), true) {
+					out <- e
+				}
+				close(out)
+				return
+			}
+			for _, e := range c.Add(event, time.Now()) {
+				out <- e
+			}
+		case now := <-ticker.C:
+			for _, e := range c.Flush(now, false) {
+				out <- e
+			}
+		}
+	}
+}
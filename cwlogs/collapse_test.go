@@ -0,0 +1,77 @@
+package cwlogs
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMaskVariableTokens(t *testing.T) {
+	masked := MaskVariableTokens("GET /users/42 took 17ms request=3f2a9c1d-0b1e-4c6f-9a2d-1e2f3a4b5c6d at 0x1f")
+	if masked != "GET /users/# took #ms request=# at #" {
+		t.Errorf("Unexpected masked message %q", masked)
+	}
+}
+
+func TestCollapser(t *testing.T) {
+	collapser := NewCollapser(false, time.Minute)
+	var output []CWLEvent
+	for _, e := range []CWLEvent{
+		testEvent("a", 0, "health check ok"),
+		testEvent("a", 1, "health check ok"),
+		testEvent("b", 2, "request 1 done"),
+		testEvent("a", 3, "health check ok"),
+		testEvent("b", 4, "request 2 done"),
+		testEvent("a", 5, "shutting down"),
+	} {
+		output = append(output, collapser.Add(e, joinStart)...)
+	}
+	output = append(output, collapser.Flush(joinStart, true)...)
+	expected := []string{
+		"a:health check ok",
+		"b:request 1 done",
+		"a:last message repeated 2 times",
+		"a:shutting down",
+		"b:similar messages repeated 1 times",
+	}
+	if !reflect.DeepEqual(messages(output), expected) {
+		t.Errorf("\nExpected: %q\n  Actual: %q", expected, messages(output))
+	}
+	note := output[2].(*RepeatNote)
+	if note.Timestamp() != joinStart.Add(3*time.Millisecond) || note.CWLEvent != output[0] {
+		t.Errorf("Unexpected note %+v", note)
+	}
+}
+
+func TestCollapserGlobal(t *testing.T) {
+	collapser := NewCollapser(true, time.Minute)
+	var output []CWLEvent
+	for _, e := range []CWLEvent{
+		testEvent("a", 0, "retrying"),
+		testEvent("b", 1, "retrying"),
+		testEvent("a", 2, "failed"),
+	} {
+		output = append(output, collapser.Add(e, joinStart)...)
+	}
+	expected := []string{"a:retrying", "a:last message repeated 1 times", "a:failed"}
+	if !reflect.DeepEqual(messages(output), expected) {
+		t.Errorf("\nExpected: %q\n  Actual: %q", expected, messages(output))
+	}
+}
+
+func TestCollapserFlushesPeriodically(t *testing.T) {
+	collapser := NewCollapser(false, 10*time.Second)
+	collapser.Add(testEvent("a", 0, "tick"), joinStart)
+	collapser.Add(testEvent("a", 5000, "tick"), joinStart.Add(5*time.Second))
+	if flushed := collapser.Flush(joinStart.Add(8*time.Second), false); len(flushed) != 0 {
+		t.Errorf("Expected no notes, got %v", messages(flushed))
+	}
+	flushed := collapser.Flush(joinStart.Add(11*time.Second), false)
+	if !reflect.DeepEqual(messages(flushed), []string{"a:last message repeated 1 times"}) {
+		t.Errorf("Unexpected notes %v", messages(flushed))
+	}
+	// the message keeps repeating without being displayed again
+	if added := collapser.Add(testEvent("a", 12000, "tick"), joinStart.Add(12*time.Second)); len(added) != 0 {
+		t.Errorf("Expected repeated message to be suppressed, got %v", messages(added))
+	}
+}
@@ -43,12 +43,12 @@ type LogGroupConfig struct {
 
 // TimeToAws converts a Time to a millisecond epoch timestamp
 func TimeToAws(tm time.Time) int64 {
-	return tm.Unix() * 1000
+	return tm.UnixNano() / int64(time.Millisecond)
 }
 
 // AwsToTime converts millisecond epoch timestamp to a Time
 func AwsToTime(ts int64) time.Time {
-	return time.Unix(ts/1000, 0)
+	return time.Unix(ts/1000, (ts%1000)*int64(time.Millisecond))
 }
 
 // AwsToMs converts millisecond epoch timestamp to a seconds epoch time
@@ -15,11 +15,15 @@ import (
 
 const (
 	renewalDelay = 15 * time.Second
+	// PollInterval is the time between requests for new events in tailing mode
+	PollInterval = 250 * time.Millisecond
+	// maxStreamNames is the maximum number of stream names in a single FilterLogEvents request
+	maxStreamNames = 100
 )
 
 type LogStreams interface {
-	Get() *logStream
-	Update(stream logStream)
+	Get() []logStream
+	Update(streams []logStream)
 }
 
 type logStreamsImpl struct {
@@ -27,38 +31,26 @@ type logStreamsImpl struct {
 	streams []logStream
 }
 
-func (ls *logStreamsImpl) Get() *logStream {
+func (ls *logStreamsImpl) Get() []logStream {
 	ls.RLock()
 	defer ls.RUnlock()
-	if len(ls.streams) > 0 {
-		return &ls.streams[0]
-	}
-	return nil
+	return ls.streams
 }
 
-func (ls *logStreamsImpl) Update(logs logStream) {
+func (ls *logStreamsImpl) Update(logs []logStream) {
 	ls.Lock()
 	defer ls.Unlock()
-	var sn []string
-	if len(logs.streamNames) > 100 {
-		log.Tracef("Too many stream names %d, taking last 100", len(logs.streamNames))
-		sn = logs.streamNames[len(logs.streamNames)-100:]
-	} else {
-		sn = logs.streamNames
-	}
-
-	ls.streams = []logStream{
-		{
-			logGroup:    logs.logGroup,
-			streamNames: sn,
-		},
-	}
+	streams := make([]logStream, len(logs))
+	copy(streams, logs)
+	ls.streams = streams
+	countStreams(streams)
 }
 
 type LogStreamingContext struct {
 	Client       *cloudwatchlogs.Client
 	Streams      LogStreams
-	Dedupe       Deduplicator
+	StreamNames  []string
+	Dedupe       map[string]Deduplicator
 	StartTime    *time.Time
 	EndTime      *time.Time
 	EventChannel chan CWLEvent
@@ -77,6 +69,9 @@ type CWLEvent interface {
 	LogGroup() string
 	LogStream() string
 	ShortStreamName() string
+	// Level returns the log level detected in the event message, LevelUnknown if level was not detected
+	Level() Level
+	SetLevel(level Level)
 }
 
 type cwlEventImpl struct {
@@ -85,6 +80,7 @@ type cwlEventImpl struct {
 	message   string
 	logGroup  string
 	logStream string
+	level     Level
 }
 
 func (c cwlEventImpl) EventID() string {
@@ -96,7 +92,7 @@ func (c cwlEventImpl) Timestamp() time.Time {
 }
 
 func (c cwlEventImpl) Message() string {
-	return strings.TrimRight(c.message, "\n\r")
+	return strings.ReplaceAll(strings.TrimRight(c.message, "\n\r"), "\r\n", "\n")
 }
 
 func (c cwlEventImpl) LogGroup() string {
@@ -111,10 +107,29 @@ func (c cwlEventImpl) ShortStreamName() string {
 	return c.logStream[len(c.logStream)-6:]
 }
 
+func (c cwlEventImpl) Level() Level {
+	return c.level
+}
+
+func (c *cwlEventImpl) SetLevel(level Level) {
+	c.level = level
+}
+
 // getStreams returns a slice of logGroup/streamName pairs for each passed log group
 func (ctx *LogStreamingContext) getStreams(logGroups []string) ([]logStream, error) {
 	result := make([]logStream, 0)
 
+	if len(ctx.StreamNames) > 0 {
+		// streams were explicitly selected, no need to discover them
+		for _, logGroup := range logGroups {
+			result = append(result, logStream{
+				logGroup:    logGroup,
+				streamNames: ctx.StreamNames,
+			})
+		}
+		return result, nil
+	}
+
 	groupStreams := make(map[string][]string, 0)
 
 	for _, logGroup := range logGroups {
@@ -129,6 +144,7 @@ func (ctx *LogStreamingContext) getStreams(logGroups []string) ([]logStream, err
 		for paginator.HasMorePages() {
 			log.Tracef("Next page within log group %s", logGroup)
 			output, err := paginator.NextPage(context.TODO())
+			countCall(err)
 			if err != nil {
 				// TODO: Handle rate error and force timeout
 				return nil, err
@@ -136,11 +152,14 @@ func (ctx *LogStreamingContext) getStreams(logGroups []string) ([]logStream, err
 			for _, s := range output.LogStreams {
 				log.Tracef("Stream %s, last event: %d, start time: %d", *s.LogStreamName, *s.LastEventTimestamp, TimeToAws(*ctx.StartTime))
 				if ctx.EndTime != nil {
-					if *s.LastEventTimestamp < TimeToAws(*ctx.StartTime) ||
-						*s.FirstEventTimestamp > TimeToAws(*ctx.EndTime) {
-						// for range mode ignore everything that ends before or starts after the range
+					if *s.LastEventTimestamp < TimeToAws(*ctx.StartTime) {
+						// for range mode ignore everything that ends before the range
 						break out
 					}
+					if *s.FirstEventTimestamp > TimeToAws(*ctx.EndTime) {
+						// and everything that starts after it
+						continue
+					}
 				} else if (TimeToAws(*ctx.StartTime) - *s.LastEventTimestamp) > 3600000 {
 					// for tailing mode ignore all streams that have last event from more than an hour ago
 					break out
@@ -173,48 +192,75 @@ func (ctx *LogStreamingContext) getStreams(logGroups []string) ([]logStream, err
 	return result, nil
 }
 
-func (ctx *LogStreamingContext) readEventsFromLogGroup() {
+// streamBatches splits stream names into batches small enough for a single FilterLogEvents request.
+// Empty list of names, which means all the streams of the group, results in a single empty batch
+func streamBatches(streamNames []string) [][]string {
+	if len(streamNames) <= maxStreamNames {
+		return [][]string{streamNames}
+	}
+	var batches [][]string
+	for start := 0; start < len(streamNames); start += maxStreamNames {
+		end := start + maxStreamNames
+		if end > len(streamNames) {
+			end = len(streamNames)
+		}
+		batches = append(batches, streamNames[start:end])
+	}
+	return batches
+}
+
+func (ctx *LogStreamingContext) readEventsFromLogGroup(stream logStream) {
 	var starting int64
-	var ending int64
-	if ctx.Dedupe.GetLastTimestamp() == 0 {
+	dedupe := ctx.Dedupe[stream.logGroup]
+	if dedupe.GetLastTimestamp() == 0 {
 		starting = TimeToAws(*ctx.StartTime)
 	} else {
-		starting = ctx.Dedupe.GetLastTimestamp()
+		starting = dedupe.GetLastTimestamp()
 	}
 
-	stream := ctx.Streams.Get()
-	if stream == nil {
-		log.Traceln("No streams found")
-		return
-	}
 	log.Tracef("Log streams %v", stream.streamNames)
+	// all the batches start at the same time, events read from one batch must not skip events of the others
+	for _, streamNames := range streamBatches(stream.streamNames) {
+		ctx.readEventsFromStreams(stream.logGroup, streamNames, starting)
+	}
+	log.Traceln("Stream read done")
+}
 
+func (ctx *LogStreamingContext) readEventsFromStreams(logGroup string, streamNames []string, starting int64) {
+	dedupe := ctx.Dedupe[logGroup]
 	params := cloudwatchlogs.FilterLogEventsInput{
-		LogGroupName:   aws.String(stream.logGroup),
-		LogStreamNames: stream.streamNames,
+		LogGroupName:   aws.String(logGroup),
+		LogStreamNames: streamNames,
 		StartTime:      aws.Int64(starting),
 	}
 	if ctx.EndTime != nil {
-		ending = TimeToAws(*ctx.EndTime)
-		params.EndTime = aws.Int64(ending)
+		params.EndTime = aws.Int64(TimeToAws(*ctx.EndTime))
 	}
-	log.Tracef("Get events from group %s, # of streams: %d", stream.logGroup, len(stream.streamNames))
+	log.Tracef("Get events from group %s, # of streams: %d", logGroup, len(streamNames))
 
 	paginator := cloudwatchlogs.NewFilterLogEventsPaginator(ctx.Client, &params)
-	if paginator.HasMorePages() {
-		log.Tracef("Reading next page of events from %s", stream.logGroup)
+	// in tailing mode read one page at a time, the rest will be read on the next tick.
+	// in range mode read everything
+	for paginator.HasMorePages() {
+		log.Tracef("Reading next page of events from %s", logGroup)
 		output, err := paginator.NextPage(context.TODO())
 		if err != nil {
+			countCall(err)
 			log.Errorln(err)
 			// TODO: Send error to err channel
-		} else {
-			log.Tracef("Got %d events from %s", len(output.Events), stream.logGroup)
+			return
 		}
+		log.Tracef("Got %d events from %s", len(output.Events), logGroup)
+		messages := make([]*string, len(output.Events))
+		for i, e := range output.Events {
+			messages[i] = e.Message
+		}
+		countCall(nil, messages...)
 		for _, e := range output.Events {
-			ctx.Dedupe.AddAndExecuteIfNotPresent(*e.EventId, *e.Timestamp, func() {
+			dedupe.AddAndExecuteIfNotPresent(*e.EventId, *e.Timestamp, func() {
 				cwlEvent := &cwlEventImpl{
 					eventID:   *e.EventId,
-					logGroup:  stream.logGroup,
+					logGroup:  logGroup,
 					logStream: *e.LogStreamName,
 					timestamp: AwsToTime(*e.Timestamp),
 					message:   *e.Message,
@@ -222,8 +268,21 @@ func (ctx *LogStreamingContext) readEventsFromLogGroup() {
 				ctx.EventChannel <- cwlEvent
 			})
 		}
+		if ctx.EndTime == nil {
+			break
+		}
+	}
+}
+
+// readEvents reads events from all the log groups
+func (ctx *LogStreamingContext) readEvents() {
+	streams := ctx.Streams.Get()
+	if len(streams) == 0 {
+		log.Traceln("No streams found")
+	}
+	for _, stream := range streams {
+		ctx.readEventsFromLogGroup(stream)
 	}
-	log.Traceln("Stream read done")
 	if ctx.EndTime != nil {
 		log.Traceln("Closing event channel")
 		close(ctx.EventChannel)
@@ -233,31 +292,36 @@ func (ctx *LogStreamingContext) readEventsFromLogGroup() {
 func (ctx *LogStreamingContext) streamRenewal(t *time.Ticker, logGroups []string) {
 	for range t.C {
 		log.Traceln("Stream renewal time")
-		streams, err := ctx.getStreams(logGroups[0:1])
+		streams, err := ctx.getStreams(logGroups)
 		if err == nil && len(streams) > 0 {
-			ctx.Streams.Update(streams[0])
+			ctx.Streams.Update(streams)
 		}
 	}
 }
 
 // Log starts reading events from the client and posting them to eventChannel
-// Currently only the first log group from logGroups is used
-func Log(client *cloudwatchlogs.Client, eventChannel chan CWLEvent, logGroups []string, startTime *time.Time, endTime *time.Time) {
+// Events are read from all the streams of each of logGroups, unless streamNames are provided,
+// in which case only events from those streams are read
+func Log(client *cloudwatchlogs.Client, eventChannel chan CWLEvent, logGroups []string, streamNames []string, startTime *time.Time, endTime *time.Time) {
 	ctx := LogStreamingContext{
 		Client:       client,
-		Dedupe:       NewDeduplicator(-1, -1),
+		Dedupe:       make(map[string]Deduplicator, len(logGroups)),
 		EventChannel: eventChannel,
 		StartTime:    startTime,
 		EndTime:      endTime,
 		Streams:      &logStreamsImpl{},
+		StreamNames:  streamNames,
+	}
+	for _, logGroup := range logGroups {
+		ctx.Dedupe[logGroup] = NewDeduplicator(-1, -1)
 	}
-	streams, err := ctx.getStreams(logGroups[0:1])
+	streams, err := ctx.getStreams(logGroups)
 	if err != nil {
 		log.Fatalln(err)
 	}
 
 	if len(streams) > 0 {
-		ctx.Streams.Update(streams[0])
+		ctx.Streams.Update(streams)
 	}
 
 	if ctx.StartTime == nil {
@@ -272,10 +336,10 @@ func Log(client *cloudwatchlogs.Client, eventChannel chan CWLEvent, logGroups []
 		t := time.NewTicker(renewalDelay)
 		go ctx.streamRenewal(t, logGroups)
 
-		logCheck := time.NewTicker(250 * time.Millisecond)
+		logCheck := time.NewTicker(PollInterval)
 		go func() {
 			for range logCheck.C {
-				ctx.readEventsFromLogGroup()
+				ctx.readEvents()
 			}
 		}()
 
@@ -283,7 +347,7 @@ func Log(client *cloudwatchlogs.Client, eventChannel chan CWLEvent, logGroups []
 		log.Traceln("Period CWL")
 
 		go func() {
-			ctx.readEventsFromLogGroup()
+			ctx.readEvents()
 		}()
 	}
 
@@ -0,0 +1,32 @@
+package cwlogs
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestStreamBatches(t *testing.T) {
+	names := make([]string, 250)
+	for i := range names {
+		names[i] = fmt.Sprint(i)
+	}
+	tests := []struct {
+		count    int
+		expected []int
+	}{
+		{0, []int{0}},
+		{100, []int{100}},
+		{250, []int{100, 100, 50}},
+	}
+	for _, test := range tests {
+		batches := streamBatches(names[:test.count])
+		if len(batches) != len(test.expected) {
+			t.Fatalf("%d: expected %d batches, got %d", test.count, len(test.expected), len(batches))
+		}
+		for i, batch := range batches {
+			if len(batch) != test.expected[i] || (len(batch) > 0 && batch[0] != names[i*maxStreamNames]) {
+				t.Errorf("%d: unexpected batch %d %v", test.count, i, batch)
+			}
+		}
+	}
+}
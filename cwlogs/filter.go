@@ -0,0 +1,94 @@
+package cwlogs
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+// TextFilter selects events by matching their messages with include and exclude patterns
+type TextFilter struct {
+	// Includes are patterns of the messages to display, if empty all the messages not excluded are displayed
+	Includes []*regexp.Regexp
+	// Excludes are patterns of the messages to discard, a message matching any of them is discarded
+	Excludes []*regexp.Regexp
+	// MatchAll requires messages to match all the include patterns, otherwise any of them is enough
+	MatchAll bool
+}
+
+func compileFilterPattern(pattern string, ignoreCase bool, wholeWord bool) (*regexp.Regexp, error) {
+	if wholeWord {
+		pattern = `\b(?:` + pattern + `)\b`
+	}
+	if ignoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter pattern: %v", err)
+	}
+	return re, nil
+}
+
+// NewTextFilter compiles include and exclude patterns. Include patterns prefixed with '!' are excluding patterns.
+// If ignoreCase is true then patterns are case-insensitive, if wholeWord is true then patterns match only whole words
+func NewTextFilter(includes []string, excludes []string, matchAll bool, ignoreCase bool, wholeWord bool) (*TextFilter, error) {
+	filter := &TextFilter{MatchAll: matchAll}
+	for _, pattern := range includes {
+		exclude := len(pattern) > 0 && pattern[0] == '!'
+		if exclude {
+			pattern = pattern[1:]
+		}
+		re, err := compileFilterPattern(pattern, ignoreCase, wholeWord)
+		if err != nil {
+			return nil, err
+		}
+		if exclude {
+			filter.Excludes = append(filter.Excludes, re)
+		} else {
+			filter.Includes = append(filter.Includes, re)
+		}
+	}
+	for _, pattern := range excludes {
+		re, err := compileFilterPattern(pattern, ignoreCase, wholeWord)
+		if err != nil {
+			return nil, err
+		}
+		filter.Excludes = append(filter.Excludes, re)
+	}
+	return filter, nil
+}
+
+// Match returns true if the text should be displayed. Positions of the matches are found by Highlights
+func (f *TextFilter) Match(text string) bool {
+	for _, re := range f.Excludes {
+		if re.MatchString(text) {
+			return false
+		}
+	}
+	matched := 0
+	for _, re := range f.Includes {
+		if re.MatchString(text) {
+			matched++
+		}
+	}
+	return len(f.Includes) == 0 || (matched > 0 && (!f.MatchAll || matched == len(f.Includes)))
+}
+
+// Highlights returns non-empty matches of include patterns sorted by their start. Unlike Match,
+// it doesn't decide if the text is displayed, so it is used to highlight the matches in the text rendered
+// from the message which was matched
+func (f *TextFilter) Highlights(text string) [][]int {
+	var matches [][]int
+	for _, re := range f.Includes {
+		for _, m := range re.FindAllStringIndex(text, -1) {
+			if m[0] < m[1] {
+				matches = append(matches, m)
+			}
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i][0] < matches[j][0]
+	})
+	return matches
+}
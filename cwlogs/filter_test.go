@@ -0,0 +1,64 @@
+package cwlogs
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTextFilter(t *testing.T) {
+	tests := []struct {
+		includes   []string
+		excludes   []string
+		matchAll   bool
+		ignoreCase bool
+		wholeWord  bool
+		text       string
+		accepted   bool
+		matches    [][]int
+	}{
+		{nil, nil, false, false, false, "anything", true, nil},
+		{[]string{"error", "timeout"}, nil, false, false, false, "timeout after error, error", true, [][]int{{0, 7}, {14, 19}, {21, 26}}},
+		{[]string{"error", "timeout"}, nil, true, false, false, "error only", false, nil},
+		{[]string{"error", "timeout"}, nil, true, false, false, "timeout and error", true, [][]int{{0, 7}, {12, 17}}},
+		{[]string{"error"}, []string{"health"}, false, false, false, "error in health check", false, nil},
+		{[]string{"error", "!health"}, nil, false, false, false, "error in health check", false, nil},
+		{[]string{"!health"}, nil, false, false, false, "request done", true, nil},
+		{nil, []string{"health", "metrics"}, false, false, false, "GET /metrics", false, nil},
+		{[]string{"error"}, nil, false, true, false, "ERROR: failed", true, [][]int{{0, 5}}},
+		{[]string{"error"}, nil, false, false, false, "ERROR: failed", false, nil},
+		{[]string{"err"}, nil, false, false, true, "errors: 0", false, nil},
+		{[]string{"err|warn"}, nil, false, false, true, "warn: slow, err: 1", true, [][]int{{0, 4}, {12, 15}}},
+		{nil, []string{"debug"}, false, true, true, "DEBUG cache", false, nil},
+		{[]string{"x*"}, nil, false, false, false, "abc", true, nil},
+	}
+	for i, test := range tests {
+		filter, err := NewTextFilter(test.includes, test.excludes, test.matchAll, test.ignoreCase, test.wholeWord)
+		if err != nil {
+			t.Errorf("%d: unexpected error %v", i, err)
+			continue
+		}
+		accepted := filter.Match(test.text)
+		var matches [][]int
+		if accepted {
+			matches = filter.Highlights(test.text)
+		}
+		if accepted != test.accepted || !reflect.DeepEqual(matches, test.matches) {
+			t.Errorf("%d: '%s'\nExpected: %v %v\n  Actual: %v %v", i, test.text, test.accepted, test.matches, accepted, matches)
+		}
+	}
+	if _, err := NewTextFilter([]string{"("}, nil, false, false, false); err == nil {
+		t.Errorf("Expected error for invalid pattern")
+	}
+}
+
+func TestTextFilterHighlights(t *testing.T) {
+	filter, err := NewTextFilter([]string{"error", "timeout"}, []string{"health"}, true, false, false)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	// highlights are found even in the text which is not accepted by itself, e.g. rendered fields of the message
+	expected := [][]int{{4, 9}, {20, 25}}
+	if actual := filter.Highlights("msg=error in health error"); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("\nExpected: %v\n  Actual: %v", expected, actual)
+	}
+}
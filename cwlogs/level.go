@@ -0,0 +1,139 @@
+package cwlogs
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/uaraven/cwltail/query"
+)
+
+// Level is a log level of the event
+type Level int
+
+// Log levels in the order of increasing severity
+const (
+	LevelUnknown Level = iota
+	LevelTrace
+	LevelDebug
+	LevelInfo
+	LevelWarning
+	LevelError
+	LevelFatal
+)
+
+var levelNames = []string{"unknown", "trace", "debug", "info", "warning", "error", "fatal"}
+
+var levelAliases = map[string]Level{
+	"trace":    LevelTrace,
+	"debug":    LevelDebug,
+	"info":     LevelInfo,
+	"warn":     LevelWarning,
+	"warning":  LevelWarning,
+	"error":    LevelError,
+	"err":      LevelError,
+	"fatal":    LevelFatal,
+	"critical": LevelFatal,
+	"crit":     LevelFatal,
+}
+
+// DefaultLevelPattern detects all the supported log levels
+const DefaultLevelPattern = `(?i)\b(?:(?P<trace>trace)|(?P<debug>debug)|(?P<info>info)|(?P<warning>warn|warning)|(?P<error>error)|(?P<fatal>fatal|critical))\b`
+
+func (l Level) String() string {
+	if l < LevelUnknown || int(l) >= len(levelNames) {
+		return levelNames[LevelUnknown]
+	}
+	return levelNames[l]
+}
+
+// ParseLevel converts level name, such as "warn", "WARNING" or "error", into a Level
+func ParseLevel(name string) (Level, error) {
+	if level, ok := levelAliases[strings.ToLower(strings.TrimSpace(name))]; ok {
+		return level, nil
+	}
+	return LevelUnknown, fmt.Errorf("unknown log level '%s'", name)
+}
+
+// DetectLevel finds the log level in the message using pattern with named groups, named after the levels.
+// If several groups match, the most severe level is returned. Positions of the matched level token in the
+// message are returned as well, or nil if no level was detected
+func DetectLevel(pattern *regexp.Regexp, message string) (Level, []int) {
+	indices := pattern.FindStringSubmatchIndex(message)
+	if indices == nil {
+		return LevelUnknown, nil
+	}
+	detected := LevelUnknown
+	var token []int
+	for i, name := range pattern.SubexpNames() {
+		if i == 0 || name == "" || indices[2*i] < 0 {
+			continue
+		}
+		level, err := ParseLevel(name)
+		if err != nil {
+			continue
+		}
+		if level > detected {
+			detected = level
+			token = indices[2*i : 2*i+2]
+		}
+	}
+	return detected, token
+}
+
+// DetectLevelFromFields detects log level from a field of the parsed message. path contains names of the fields
+// leading to the level field, e.g. ["log", "level"] for {"log": {"level": "WARN"}}
+func DetectLevelFromFields(fields *query.Object, path []string) Level {
+	if fields == nil {
+		return LevelUnknown
+	}
+	value, _ := fields.Lookup(path)
+	return LevelFromValue(value)
+}
+
+// LevelFromValue converts a value of the level field of the JSON message into a Level
+func LevelFromValue(value interface{}) Level {
+	name, ok := value.(string)
+	if !ok {
+		return LevelUnknown
+	}
+	level, err := ParseLevel(name)
+	if err != nil {
+		return LevelUnknown
+	}
+	return level
+}
+
+// LevelFilter decides which events are displayed based on their log level
+type LevelFilter struct {
+	// MinLevel is the least severe level of the events to display
+	MinLevel Level
+	// Levels, if not empty, is the set of levels of the events to display
+	Levels map[Level]bool
+	// KeepUnknown enables display of events without detected level
+	KeepUnknown bool
+}
+
+// ParseLevelList parses comma-separated list of level names
+func ParseLevelList(list string) (map[Level]bool, error) {
+	result := make(map[Level]bool)
+	for _, name := range strings.Split(list, ",") {
+		level, err := ParseLevel(name)
+		if err != nil {
+			return nil, err
+		}
+		result[level] = true
+	}
+	return result, nil
+}
+
+// Accept returns true if the event of the level should be displayed
+func (f *LevelFilter) Accept(level Level) bool {
+	if level == LevelUnknown {
+		return f.KeepUnknown
+	}
+	if len(f.Levels) > 0 {
+		return f.Levels[level]
+	}
+	return level >= f.MinLevel
+}
@@ -0,0 +1,144 @@
+package cwlogs
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/uaraven/cwltail/query"
+)
+
+func TestDetectLevel(t *testing.T) {
+	pattern := regexp.MustCompile(DefaultLevelPattern)
+	tests := []struct {
+		message  string
+		expected Level
+		token    []int
+	}{
+		{"12:00:01 TRACE entering", LevelTrace, []int{9, 14}},
+		{"DEBUG cache miss", LevelDebug, []int{0, 5}},
+		{"[info] started", LevelInfo, []int{1, 5}},
+		{"WARN disk is almost full", LevelWarning, []int{0, 4}},
+		{"level=warning slow query", LevelWarning, []int{6, 13}},
+		{"Error: connection refused", LevelError, []int{0, 5}},
+		{"FATAL out of memory", LevelFatal, []int{0, 5}},
+		{"CRITICAL out of memory", LevelFatal, []int{0, 8}},
+		{"request completed", LevelUnknown, nil},
+		{"errors=0", LevelUnknown, nil},
+	}
+	for _, test := range tests {
+		level, token := DetectLevel(pattern, test.message)
+		if level != test.expected || !reflect.DeepEqual(token, test.token) {
+			t.Errorf("'%s'\nExpected: %v %v\n  Actual: %v %v", test.message, test.expected, test.token, level, token)
+		}
+	}
+}
+
+func TestDetectLevelPicksMostSevereGroup(t *testing.T) {
+	pattern := regexp.MustCompile(`(?P<warning>slow)?.*(?P<error>failed)?`)
+	level, _ := DetectLevel(pattern, "slow request failed")
+	if level != LevelWarning {
+		t.Errorf("Expected warning, got %v", level)
+	}
+	pattern = regexp.MustCompile(`(?P<warning>slow).*(?P<error>failed)`)
+	level, token := DetectLevel(pattern, "slow request failed")
+	if level != LevelError || !reflect.DeepEqual(token, []int{13, 19}) {
+		t.Errorf("Expected error at [13 19], got %v %v", level, token)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := map[string]Level{
+		"trace":    LevelTrace,
+		"DEBUG":    LevelDebug,
+		"Info":     LevelInfo,
+		"warn":     LevelWarning,
+		"warning":  LevelWarning,
+		"err":      LevelError,
+		"fatal":    LevelFatal,
+		"critical": LevelFatal,
+	}
+	for name, expected := range tests {
+		level, err := ParseLevel(name)
+		if err != nil || level != expected {
+			t.Errorf("'%s': expected %v, got %v %v", name, expected, level, err)
+		}
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Errorf("Expected error for unknown level")
+	}
+}
+
+func TestDetectLevelFromFields(t *testing.T) {
+	tests := []struct {
+		message  string
+		path     []string
+		expected Level
+	}{
+		{`{"level":"WARN","msg":"slow"}`, []string{"level"}, LevelWarning},
+		{`  {"log":{"severity":"error"}}`, []string{"log", "severity"}, LevelError},
+		{`{"level":30}`, []string{"level"}, LevelUnknown},
+		{`{"level":"verbose"}`, []string{"level"}, LevelUnknown},
+		{`{"log":"error"}`, []string{"log", "severity"}, LevelUnknown},
+		{`ERROR plain text`, []string{"level"}, LevelUnknown},
+		{`{"level":"error"`, []string{"level"}, LevelUnknown},
+	}
+	for _, test := range tests {
+		fields, _ := query.ParseJSON(test.message)
+		actual := DetectLevelFromFields(fields, test.path)
+		if actual != test.expected {
+			t.Errorf("'%s': expected %v, got %v", test.message, test.expected, actual)
+		}
+	}
+}
+
+func TestLevelFilter(t *testing.T) {
+	minLevel := LevelFilter{MinLevel: LevelWarning, KeepUnknown: true}
+	levels, err := ParseLevelList("error, fatal")
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	explicit := LevelFilter{Levels: levels}
+
+	tests := []struct {
+		level       Level
+		minAccepted bool
+		setAccepted bool
+	}{
+		{LevelDebug, false, false},
+		{LevelInfo, false, false},
+		{LevelWarning, true, false},
+		{LevelError, true, true},
+		{LevelFatal, true, true},
+		{LevelUnknown, true, false},
+	}
+	for _, test := range tests {
+		if minLevel.Accept(test.level) != test.minAccepted {
+			t.Errorf("min level filter, %v: expected %v", test.level, test.minAccepted)
+		}
+		if explicit.Accept(test.level) != test.setAccepted {
+			t.Errorf("level set filter, %v: expected %v", test.level, test.setAccepted)
+		}
+	}
+	if _, err = ParseLevelList("error,loud"); err == nil {
+		t.Errorf("Expected error for unknown level")
+	}
+}
+
+func TestLevelFromValue(t *testing.T) {
+	tests := []struct {
+		value    interface{}
+		expected Level
+	}{
+		{"WARN", LevelWarning},
+		{"critical", LevelFatal},
+		{"verbose", LevelUnknown},
+		{30, LevelUnknown},
+		{nil, LevelUnknown},
+	}
+	for _, test := range tests {
+		if actual := LevelFromValue(test.value); actual != test.expected {
+			t.Errorf("%v: expected %v, got %v", test.value, test.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package cwlogs
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// lastEventWorkers is the number of log groups the time of the last event is requested for at once
+	lastEventWorkers = 4
+	// throttlingRetries is the number of times a throttled request is retried
+	throttlingRetries = 5
+)
+
+// throttlingBackoff is the delay before the first retry of a throttled request, it doubles with every retry
+var throttlingBackoff = 200 * time.Millisecond
+
+// ListClient is the part of Cloudwatch Logs API used to list log groups and streams
+type ListClient interface {
+	cloudwatchlogs.DescribeLogGroupsAPIClient
+	cloudwatchlogs.DescribeLogStreamsAPIClient
+}
+
+// LogGroupInfo describes a single log group
+type LogGroupInfo struct {
+	Name            string     `json:"name"`
+	StoredBytes     int64      `json:"storedBytes"`
+	RetentionInDays int32      `json:"retentionInDays,omitempty"`
+	CreationTime    time.Time  `json:"creationTime"`
+	LastEventTime   *time.Time `json:"lastEventTime,omitempty"`
+}
+
+// LogStreamInfo describes a single log stream
+type LogStreamInfo struct {
+	Name           string     `json:"name"`
+	StoredBytes    int64      `json:"storedBytes"`
+	FirstEventTime *time.Time `json:"firstEventTime,omitempty"`
+	LastEventTime  *time.Time `json:"lastEventTime,omitempty"`
+}
+
+func optionalTime(ts *int64) *time.Time {
+	if ts == nil {
+		return nil
+	}
+	t := AwsToTime(*ts)
+	return &t
+}
+
+// withBackoff makes the request until it succeeds, fails with other error than throttling or runs out of retries.
+// The delay between the requests doubles after each throttled one
+func withBackoff(request func() error) error {
+	delay := throttlingBackoff
+	for retry := 0; ; retry++ {
+		err := request()
+		if err == nil || !isThrottling(err) || retry == throttlingRetries {
+			return err
+		}
+		log.Debugf("Request throttled, retrying in %s", delay)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// lastEventTime returns the timestamp of the most recent event in the log group
+func lastEventTime(client cloudwatchlogs.DescribeLogStreamsAPIClient, logGroup string) (*time.Time, error) {
+	var output *cloudwatchlogs.DescribeLogStreamsOutput
+	err := withBackoff(func() (err error) {
+		output, err = client.DescribeLogStreams(context.TODO(), &cloudwatchlogs.DescribeLogStreamsInput{
+			LogGroupName: aws.String(logGroup),
+			OrderBy:      types.OrderByLastEventTime,
+			Descending:   aws.Bool(true),
+			Limit:        aws.Int32(1),
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(output.LogStreams) == 0 {
+		return nil, nil
+	}
+	return optionalTime(output.LogStreams[0].LastEventTimestamp), nil
+}
+
+// fillLastEventTimes requests the time of the last event of each log group, for several groups at once.
+// If the request fails, the error is logged and the time of the last event of the group is left unknown
+func fillLastEventTimes(client cloudwatchlogs.DescribeLogStreamsAPIClient, groups []LogGroupInfo) {
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < lastEventWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indices {
+				last, err := lastEventTime(client, groups[index].Name)
+				if err != nil {
+					log.Warnf("Failed to get the last event time of %s: %v", groups[index].Name, err)
+					continue
+				}
+				groups[index].LastEventTime = last
+			}
+		}()
+	}
+	for i := range groups {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+}
+
+// ListGroups returns all log groups which names start with prefix, ordered by name, with the time of
+// the last event in each of them
+func ListGroups(client ListClient, prefix string) ([]LogGroupInfo, error) {
+	result := make([]LogGroupInfo, 0)
+
+	params := &cloudwatchlogs.DescribeLogGroupsInput{}
+	if prefix != "" {
+		params.LogGroupNamePrefix = aws.String(prefix)
+	}
+
+	paginator := cloudwatchlogs.NewDescribeLogGroupsPaginator(client, params)
+	for paginator.HasMorePages() {
+		log.Tracef("Next page of log groups with prefix '%s'", prefix)
+		var output *cloudwatchlogs.DescribeLogGroupsOutput
+		err := withBackoff(func() (err error) {
+			output, err = paginator.NextPage(context.TODO())
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, g := range output.LogGroups {
+			result = append(result, LogGroupInfo{
+				Name:            *g.LogGroupName,
+				StoredBytes:     aws.ToInt64(g.StoredBytes),
+				RetentionInDays: aws.ToInt32(g.RetentionInDays),
+				CreationTime:    AwsToTime(aws.ToInt64(g.CreationTime)),
+			})
+		}
+	}
+	fillLastEventTimes(client, result)
+	return result, nil
+}
+
+// ListGroupNames returns names of all log groups which names start with prefix, ordered by name
+func ListGroupNames(client cloudwatchlogs.DescribeLogGroupsAPIClient, prefix string) ([]string, error) {
+	result := make([]string, 0)
+
+	params := &cloudwatchlogs.DescribeLogGroupsInput{}
+	if prefix != "" {
+		params.LogGroupNamePrefix = aws.String(prefix)
+	}
+
+	paginator := cloudwatchlogs.NewDescribeLogGroupsPaginator(client, params)
+	for paginator.HasMorePages() {
+		log.Tracef("Next page of log group names with prefix '%s'", prefix)
+		var output *cloudwatchlogs.DescribeLogGroupsOutput
+		err := withBackoff(func() (err error) {
+			output, err = paginator.NextPage(context.TODO())
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, g := range output.LogGroups {
+			result = append(result, *g.LogGroupName)
+		}
+	}
+	return result, nil
+}
+
+// ListStreams returns all log streams of the log group, most recently active streams first
+func ListStreams(client cloudwatchlogs.DescribeLogStreamsAPIClient, logGroup string) ([]LogStreamInfo, error) {
+	result := make([]LogStreamInfo, 0)
+
+	params := &cloudwatchlogs.DescribeLogStreamsInput{
+		LogGroupName: aws.String(logGroup),
+		OrderBy:      types.OrderByLastEventTime,
+		Descending:   aws.Bool(true),
+	}
+
+	paginator := cloudwatchlogs.NewDescribeLogStreamsPaginator(client, params)
+	for paginator.HasMorePages() {
+		log.Tracef("Next page within log group %s", logGroup)
+		var output *cloudwatchlogs.DescribeLogStreamsOutput
+		err := withBackoff(func() (err error) {
+			output, err = paginator.NextPage(context.TODO())
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range output.LogStreams {
+			result = append(result, LogStreamInfo{
+				Name:           *s.LogStreamName,
+				StoredBytes:    aws.ToInt64(s.StoredBytes),
+				FirstEventTime: optionalTime(s.FirstEventTimestamp),
+				LastEventTime:  optionalTime(s.LastEventTimestamp),
+			})
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,124 @@
+package cwlogs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/aws/smithy-go"
+)
+
+// fakeListClient returns pages of two log groups and throttles the first requests for the last event time
+type fakeListClient struct {
+	sync.Mutex
+	groups    []string
+	throttled map[string]int
+	failed    string
+	requests  map[string]int
+}
+
+func (c *fakeListClient) DescribeLogGroups(_ context.Context, input *cloudwatchlogs.DescribeLogGroupsInput,
+	_ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DescribeLogGroupsOutput, error) {
+	start := 0
+	if input.NextToken != nil {
+		fmt.Sscan(*input.NextToken, &start)
+	}
+	output := &cloudwatchlogs.DescribeLogGroupsOutput{}
+	for i := start; i < len(c.groups) && i < start+2; i++ {
+		output.LogGroups = append(output.LogGroups, types.LogGroup{
+			LogGroupName: aws.String(c.groups[i]),
+			StoredBytes:  aws.Int64(int64(i)),
+			CreationTime: aws.Int64(0),
+		})
+	}
+	if start+2 < len(c.groups) {
+		output.NextToken = aws.String(fmt.Sprint(start + 2))
+	}
+	return output, nil
+}
+
+func (c *fakeListClient) DescribeLogStreams(_ context.Context, input *cloudwatchlogs.DescribeLogStreamsInput,
+	_ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DescribeLogStreamsOutput, error) {
+	c.Lock()
+	defer c.Unlock()
+	group := *input.LogGroupName
+	c.requests[group]++
+	if group == c.failed {
+		return nil, errors.New("access denied")
+	}
+	if c.requests[group] <= c.throttled[group] {
+		return nil, &smithy.GenericAPIError{Code: "ThrottlingException", Message: "Rate exceeded"}
+	}
+	output := &cloudwatchlogs.DescribeLogStreamsOutput{}
+	if group != "/empty" {
+		output.LogStreams = []types.LogStream{{LogStreamName: aws.String("s"), LastEventTimestamp: aws.Int64(int64(len(group)) * 1000)}}
+	}
+	return output, nil
+}
+
+func TestListGroups(t *testing.T) {
+	defer func(backoff time.Duration) { throttlingBackoff = backoff }(throttlingBackoff)
+	throttlingBackoff = time.Millisecond
+	client := &fakeListClient{
+		groups:    []string{"/a", "/bb", "/ccc", "/empty", "/eeeee"},
+		throttled: map[string]int{"/bb": 2},
+		requests:  make(map[string]int),
+	}
+	groups, err := ListGroups(client, "")
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if len(groups) != len(client.groups) {
+		t.Fatalf("Expected %d groups, got %+v", len(client.groups), groups)
+	}
+	for i, g := range groups {
+		if g.Name != client.groups[i] || g.StoredBytes != int64(i) {
+			t.Errorf("Unexpected group %+v", g)
+		}
+		if g.Name == "/empty" {
+			if g.LastEventTime != nil {
+				t.Errorf("Expected no last event time in the empty group, got %v", g.LastEventTime)
+			}
+			continue
+		}
+		if g.LastEventTime == nil || !g.LastEventTime.Equal(AwsToTime(int64(len(g.Name))*1000)) {
+			t.Errorf("Unexpected last event time of %s: %v", g.Name, g.LastEventTime)
+		}
+	}
+	if client.requests["/bb"] != 3 || client.requests["/a"] != 1 {
+		t.Errorf("Expected throttled requests to be retried, got %v", client.requests)
+	}
+
+	// failed lookup leaves the last event time of the group unknown, other groups are still listed
+	client.failed = "/ccc"
+	groups, err = ListGroups(client, "")
+	if err != nil || len(groups) != len(client.groups) {
+		t.Fatalf("Expected all groups despite the failed lookup, got %+v, %v", groups, err)
+	}
+	if groups[2].LastEventTime != nil || groups[4].LastEventTime == nil {
+		t.Errorf("Expected unknown last event time only for %s, got %+v", client.failed, groups)
+	}
+}
+
+func TestWithBackoffGivesUp(t *testing.T) {
+	defer func(backoff time.Duration) { throttlingBackoff = backoff }(throttlingBackoff)
+	throttlingBackoff = time.Millisecond
+	calls := 0
+	err := withBackoff(func() error {
+		calls++
+		return &smithy.GenericAPIError{Code: "ThrottlingException"}
+	})
+	if err == nil || calls != throttlingRetries+1 {
+		t.Errorf("Expected throttling error after %d calls, got %v after %d", throttlingRetries+1, err, calls)
+	}
+	calls = 0
+	if err = withBackoff(func() error { calls++; return errors.New("denied") }); err == nil || calls != 1 {
+		t.Errorf("Expected other errors not to be retried, got %v after %d calls", err, calls)
+	}
+}
@@ -0,0 +1,126 @@
+package cwlogs
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DefaultContinuationPattern matches events which continue the previous event of the stream: indented lines,
+// Java stack frames and "Caused by:" lines, Python tracebacks and exception lines. Exception type must be either
+// qualified, like java.lang.Error, or have a name before the suffix, like ValueError, so "Error: ..." messages
+// are not joined
+const DefaultContinuationPattern = `^(?:\s+\S|at |\.\.\. \d+ more|Caused by:|Traceback \(most recent call last\)|(?:(?:[\w$]+\.)+[\w$]*|[\w$]+)(?:Exception|Error)(?::|$))`
+
+// DefaultJoinWindow is the longest time between events which are joined
+const DefaultJoinWindow = time.Second
+
+type pendingEvent struct {
+	event   *cwlEventImpl
+	lines   []string
+	last    time.Time
+	updated time.Time
+}
+
+func (p *pendingEvent) joined() CWLEvent {
+	event := *p.event
+	event.message = strings.Join(p.lines, "\n")
+	return &event
+}
+
+// MultilineJoiner merges consecutive events of the same stream into one event, when the later events
+// continue the earlier one, e.g. when each line of a stack trace is a separate event
+type MultilineJoiner struct {
+	pattern *regexp.Regexp
+	window  time.Duration
+	pending map[string]*pendingEvent
+	order   []string
+}
+
+// NewMultilineJoiner creates a joiner which merges events matching the continuation pattern with the previous
+// event of the stream, if the time between them is within the window
+func NewMultilineJoiner(pattern *regexp.Regexp, window time.Duration) *MultilineJoiner {
+	return &MultilineJoiner{
+		pattern: pattern,
+		window:  window,
+		pending: make(map[string]*pendingEvent),
+	}
+}
+
+func (j *MultilineJoiner) take(key string) CWLEvent {
+	p := j.pending[key]
+	delete(j.pending, key)
+	for i, k := range j.order {
+		if k == key {
+			j.order = append(j.order[:i], j.order[i+1:]...)
+			break
+		}
+	}
+	return p.joined()
+}
+
+// Add adds the event received at now and returns events which are complete. The event itself is kept until
+// it is known that the next event of the stream doesn't continue it
+func (j *MultilineJoiner) Add(event CWLEvent, now time.Time) []CWLEvent {
+	key := event.LogGroup() + "/" + event.LogStream()
+	p, ok := j.pending[key]
+	if ok && event.Timestamp().Sub(p.last) <= j.window && j.pattern.MatchString(event.Message()) {
+		p.lines = append(p.lines, event.Message())
+		p.last = event.Timestamp()
+		p.updated = now
+		return nil
+	}
+	var result []CWLEvent
+	if ok {
+		result = append(result, j.take(key))
+	}
+	impl, isImpl := event.(*cwlEventImpl)
+	if !isImpl {
+		return append(result, event)
+	}
+	j.pending[key] = &pendingEvent{
+		event:   impl,
+		lines:   []string{event.Message()},
+		last:    event.Timestamp(),
+		updated: now,
+	}
+	j.order = append(j.order, key)
+	return result
+}
+
+// Flush returns pending events which were not continued for longer than the window, or all pending events
+// if all is true
+func (j *MultilineJoiner) Flush(now time.Time, all bool) []CWLEvent {
+	var result []CWLEvent
+	for _, key := range append([]string{}, j.order...) {
+		if all || now.Sub(j.pending[key].updated) > j.window {
+			result = append(result, j.take(key))
+		}
+	}
+	return result
+}
+
+// Join reads events from in, joins them and writes them to out. out is closed when in is closed
+func (j *MultilineJoiner) Join(in <-chan CWLEvent, out chan<- CWLEvent) {
+	ticker := time.NewTicker(j.window / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case event, ok := <-in:
+			if !ok {
+				for _, e := range j.Flush(time.Now(), true) {
+					out <- e
+				}
+				close(out)
+				return
+			}
+			for _, e := range j.Add(event, time.Now()) {
+				out <- e
+			}
+		case now := <-ticker.C:
+			for _, e := range j.Flush(now, false) {
+				out <- e
+			}
+		}
+	}
+}
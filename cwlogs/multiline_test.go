@@ -0,0 +1,125 @@
+package cwlogs
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+)
+
+var joinStart = time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+
+func testEvent(stream string, offsetMs int, message string) CWLEvent {
+	return &cwlEventImpl{
+		eventID:   stream + message,
+		timestamp: joinStart.Add(time.Duration(offsetMs) * time.Millisecond),
+		message:   message + "\n",
+		logGroup:  "group",
+		logStream: stream,
+	}
+}
+
+func messages(events []CWLEvent) []string {
+	var result []string
+	for _, e := range events {
+		result = append(result, e.LogStream()+":"+e.Message())
+	}
+	return result
+}
+
+func TestMultilineJoiner(t *testing.T) {
+	joiner := NewMultilineJoiner(regexp.MustCompile(DefaultContinuationPattern), time.Second)
+	var output []CWLEvent
+	for _, e := range []CWLEvent{
+		testEvent("a", 0, "ERROR request failed"),
+		testEvent("a", 1, "java.lang.IllegalStateException: boom"),
+		testEvent("b", 2, "INFO other stream"),
+		testEvent("a", 3, "\tat com.example.Service.run(Service.java:42)"),
+		testEvent("a", 4, "Caused by: java.io.IOException: closed"),
+		testEvent("a", 5, "\t... 12 more"),
+		testEvent("a", 6, "INFO next request"),
+		testEvent("b", 7, "Traceback (most recent call last):"),
+		testEvent("b", 8, `  File "app.py", line 3, in <module>`),
+		testEvent("b", 9, "ValueError: boom"),
+		// continuation which comes too late is a separate event
+		testEvent("a", 2000, "    indented"),
+	} {
+		output = append(output, joiner.Add(e, joinStart)...)
+	}
+	output = append(output, joiner.Flush(joinStart, true)...)
+	expected := []string{
+		"a:ERROR request failed\njava.lang.IllegalStateException: boom\n\tat com.example.Service.run(Service.java:42)\nCaused by: java.io.IOException: closed\n\t... 12 more",
+		"a:INFO next request",
+		"b:INFO other stream\nTraceback (most recent call last):\n  File \"app.py\", line 3, in <module>\nValueError: boom",
+		"a:    indented",
+	}
+	if !reflect.DeepEqual(messages(output), expected) {
+		t.Errorf("\nExpected: %q\n  Actual: %q", expected, messages(output))
+	}
+	if output[0].Timestamp() != joinStart {
+		t.Errorf("Expected timestamp of the first event, got %v", output[0].Timestamp())
+	}
+}
+
+func TestContinuationPatternRequiresExceptionType(t *testing.T) {
+	pattern := regexp.MustCompile(DefaultContinuationPattern)
+	for _, line := range []string{"ValueError: boom", "java.lang.Error", "java.io.IOException: closed", "$ProxyException:"} {
+		if !pattern.MatchString(line) {
+			t.Errorf("'%s' must continue the previous event", line)
+		}
+	}
+	for _, line := range []string{"Error: connection refused", "Exception: boom", "Error", "ERROR request failed"} {
+		if pattern.MatchString(line) {
+			t.Errorf("'%s' must start a new event", line)
+		}
+	}
+
+	joiner := NewMultilineJoiner(pattern, time.Second)
+	output := joiner.Add(testEvent("a", 0, "INFO connecting"), joinStart)
+	output = append(output, joiner.Add(testEvent("a", 1, "Error: connection refused"), joinStart)...)
+	output = append(output, joiner.Flush(joinStart, true)...)
+	expected := []string{"a:INFO connecting", "a:Error: connection refused"}
+	if !reflect.DeepEqual(messages(output), expected) {
+		t.Errorf("\nExpected: %q\n  Actual: %q", expected, messages(output))
+	}
+}
+
+func TestMultilineJoinerFlushesStaleEvents(t *testing.T) {
+	joiner := NewMultilineJoiner(regexp.MustCompile(DefaultContinuationPattern), time.Second)
+	joiner.Add(testEvent("a", 0, "first"), joinStart)
+	joiner.Add(testEvent("b", 0, "second"), joinStart.Add(800*time.Millisecond))
+	if flushed := joiner.Flush(joinStart.Add(500*time.Millisecond), false); len(flushed) != 0 {
+		t.Errorf("Expected no events, got %v", messages(flushed))
+	}
+	flushed := joiner.Flush(joinStart.Add(1500*time.Millisecond), false)
+	if !reflect.DeepEqual(messages(flushed), []string{"a:first"}) {
+		t.Errorf("Expected first event, got %v", messages(flushed))
+	}
+}
+
+func TestMultilineJoinerChannels(t *testing.T) {
+	joiner := NewMultilineJoiner(regexp.MustCompile(DefaultContinuationPattern), 50*time.Millisecond)
+	in := make(chan CWLEvent)
+	out := make(chan CWLEvent, 10)
+	go joiner.Join(in, out)
+	in <- testEvent("a", 0, "Exception in handler")
+	in <- testEvent("a", 1, "  at handler")
+	// the event is flushed by the timer
+	select {
+	case e := <-out:
+		if e.Message() != "Exception in handler\n  at handler" {
+			t.Errorf("Unexpected message %q", e.Message())
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Event was not flushed")
+	}
+	in <- testEvent("a", 2, "last")
+	close(in)
+	var rest []CWLEvent
+	for e := range out {
+		rest = append(rest, e)
+	}
+	if !reflect.DeepEqual(messages(rest), []string{"a:last"}) {
+		t.Errorf("Expected last event, got %v", messages(rest))
+	}
+}
@@ -0,0 +1,167 @@
+package cwlogs
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// PatternRateWindow is the period over which the rate of the pattern is computed
+const PatternRateWindow = time.Minute
+
+// maxPatterns limits the number of tracked patterns, the least frequent patterns are forgotten when it is reached
+const maxPatterns = 10000
+
+// patternTokens matches variable parts of the messages. Names of the groups are used as placeholders
+var patternTokens = regexp.MustCompile(`(?P<str>"(?:[^"\\]|\\.)*"|'(?:[^'\\]|\\.)*')` +
+	`|(?P<uuid>\b[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}\b)` +
+	`|(?P<ip>\b\d{1,3}\.\d{1,3}\.\d{1,3}\.\d{1,3}(?::\d+)?\b)` +
+	`|(?P<hex>\b0x[0-9a-fA-F]+\b|\b[0-9a-fA-F]*[0-9][0-9a-fA-F]*[a-fA-F][0-9a-fA-F]*\b|\b[0-9a-fA-F]*[a-fA-F][0-9a-fA-F]*[0-9][0-9a-fA-F]*\b)` +
+	`|(?P<id>\b[\w-]*[a-zA-Z][\w-]*\d[\w-]*\b|\b[\w-]*\d[\w-]*[a-zA-Z][\w-]*\b)` +
+	`|(?P<num>-?\d+(?:\.\d+)?)`)
+
+// PatternTemplate replaces variable tokens of the message, such as quoted strings, UUIDs, IP addresses,
+// hexadecimal and alphanumeric identifiers and numbers with placeholders, e.g. <num>
+func PatternTemplate(message string) string {
+	names := patternTokens.SubexpNames()
+	var sb strings.Builder
+	last := 0
+	for _, m := range patternTokens.FindAllStringSubmatchIndex(message, -1) {
+		sb.WriteString(message[last:m[0]])
+		for i := 1; i < len(names); i++ {
+			if m[2*i] >= 0 {
+				token := message[m[2*i]:m[2*i+1]]
+				// short words which happen to contain digits, such as utf8 or s3, are usually not identifiers
+				if names[i] == "id" && len(token) < 6 || names[i] == "hex" && len(token) < 4 {
+					sb.WriteString(token)
+				} else {
+					sb.WriteString("<" + names[i] + ">")
+				}
+				break
+			}
+		}
+		last = m[1]
+	}
+	sb.WriteString(message[last:])
+	return sb.String()
+}
+
+// rateCounter counts events in one-second buckets over the rate window
+type rateCounter struct {
+	buckets [int(PatternRateWindow / time.Second)]int
+	second  int64
+}
+
+func (r *rateCounter) advance(now time.Time) {
+	second := now.Unix()
+	if second-r.second >= int64(len(r.buckets)) {
+		r.buckets = [len(r.buckets)]int{}
+	} else {
+		for s := r.second + 1; s <= second; s++ {
+			r.buckets[s%int64(len(r.buckets))] = 0
+		}
+	}
+	if second > r.second {
+		r.second = second
+	}
+}
+
+func (r *rateCounter) add(now time.Time) {
+	r.advance(now)
+	r.buckets[r.second%int64(len(r.buckets))]++
+}
+
+func (r *rateCounter) rate(now time.Time) float64 {
+	r.advance(now)
+	total := 0
+	for _, count := range r.buckets {
+		total += count
+	}
+	return float64(total) / PatternRateWindow.Seconds()
+}
+
+// PatternStats is a message template with the number of matching messages
+type PatternStats struct {
+	Template string
+	Count    int
+	// Rate is the number of messages per second during the last PatternRateWindow
+	Rate float64
+	// Sample is the latest message matching the template
+	Sample string
+	rate   rateCounter
+}
+
+// PatternCounter groups messages by their templates
+type PatternCounter struct {
+	patterns map[string]*PatternStats
+	total    int
+}
+
+// NewPatternCounter creates an empty pattern counter
+func NewPatternCounter() *PatternCounter {
+	return &PatternCounter{patterns: make(map[string]*PatternStats)}
+}
+
+// Add counts the message received at now
+func (c *PatternCounter) Add(message string, now time.Time) {
+	template := PatternTemplate(message)
+	stats, ok := c.patterns[template]
+	if !ok {
+		if len(c.patterns) >= maxPatterns {
+			c.forgetRare()
+		}
+		stats = &PatternStats{Template: template}
+		c.patterns[template] = stats
+	}
+	stats.Count++
+	stats.Sample = message
+	stats.rate.add(now)
+	c.total++
+}
+
+// forgetRare removes the half of the patterns with the lowest counts
+func (c *PatternCounter) forgetRare() {
+	all := c.sorted()
+	for _, stats := range all[len(all)/2:] {
+		delete(c.patterns, stats.Template)
+	}
+}
+
+func (c *PatternCounter) sorted() []*PatternStats {
+	all := make([]*PatternStats, 0, len(c.patterns))
+	for _, stats := range c.patterns {
+		all = append(all, stats)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].Count != all[j].Count {
+			return all[i].Count > all[j].Count
+		}
+		return all[i].Template < all[j].Template
+	})
+	return all
+}
+
+// Top returns up to n most frequent patterns, with their rates computed at now
+func (c *PatternCounter) Top(n int, now time.Time) []PatternStats {
+	all := c.sorted()
+	if len(all) > n {
+		all = all[:n]
+	}
+	result := make([]PatternStats, len(all))
+	for i, stats := range all {
+		stats.Rate = stats.rate.rate(now)
+		result[i] = *stats
+	}
+	return result
+}
+
+// Total returns the number of counted messages
+func (c *PatternCounter) Total() int {
+	return c.total
+}
+
+// Len returns the number of distinct patterns
+func (c *PatternCounter) Len() int {
+	return len(c.patterns)
+}
@@ -0,0 +1,47 @@
+package cwlogs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPatternTemplate(t *testing.T) {
+	tests := map[string]string{
+		`GET /users/42 200 17.5ms from 10.0.0.12:443`:                          `GET /users/<num> <num> <num>ms from <ip>`,
+		`user "alice" logged in, session 3f2a9c1d-0b1e-4c6f-9a2d-1e2f3a4b5c6d`: `user <str> logged in, session <uuid>`,
+		`cache miss for key 9f86d081884c7d65 on node i-0abc123def`:             `cache miss for key <hex> on node <id>`,
+		`utf8 upload to s3 done`:                                               `utf8 upload to s3 done`,
+	}
+	for message, expected := range tests {
+		if template := PatternTemplate(message); template != expected {
+			t.Errorf("Expected template %q for %q, got %q", expected, message, template)
+		}
+	}
+}
+
+func TestPatternCounter(t *testing.T) {
+	counter := NewPatternCounter()
+	now := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+	for i, message := range []string{
+		"request 1 done",
+		"request 2 done",
+		"cache miss",
+		"request 3 done",
+	} {
+		counter.Add(message, now.Add(time.Duration(i)*time.Second))
+	}
+	top := counter.Top(1, now.Add(4*time.Second))
+	if len(top) != 1 || top[0].Template != "request <num> done" || top[0].Count != 3 || top[0].Sample != "request 3 done" {
+		t.Errorf("Unexpected top patterns %+v", top)
+	}
+	if top[0].Rate != 3/PatternRateWindow.Seconds() {
+		t.Errorf("Unexpected rate %f", top[0].Rate)
+	}
+	// events older than the window don't count towards the rate
+	if rate := counter.Top(1, now.Add(2*time.Minute))[0].Rate; rate != 0 {
+		t.Errorf("Expected zero rate, got %f", rate)
+	}
+	if counter.Total() != 4 || counter.Len() != 2 {
+		t.Errorf("Unexpected totals %d, %d", counter.Total(), counter.Len())
+	}
+}
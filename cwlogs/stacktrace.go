@@ -0,0 +1,306 @@
+package cwlogs
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Languages of the detected stack traces
+const (
+	TraceJava   = "java"
+	TraceNode   = "node"
+	TracePython = "python"
+	TraceGo     = "go"
+)
+
+// fingerprintFrames is the number of the innermost frames used for the stack trace fingerprint
+const fingerprintFrames = 5
+
+var (
+	atExceptionLine = regexp.MustCompile(`^(?:Exception in thread "[^"]*" )?((?:[\w$]+\.)*[\w$]*(?:Exception|Error|Throwable)\w*)(?::|$)`)
+	atFrameLine     = regexp.MustCompile(`^\s+at \S`)
+	atOtherLine     = regexp.MustCompile(`^(?:\s+\.\.\. \d+ (?:more|common frames omitted)|Caused by: |\s+Suppressed: )`)
+	pythonStart     = regexp.MustCompile(`^\s*Traceback \(most recent call last\):`)
+	pythonFrame     = regexp.MustCompile(`^\s+File "[^"]*", line \d+`)
+	pythonException = regexp.MustCompile(`^((?:\w+\.)*\w+)(?::|$)`)
+	goPanicLine     = regexp.MustCompile(`^panic: (.*)`)
+	goGoroutineLine = regexp.MustCompile(`^goroutine \d+ \[[^]]+\]:$`)
+	goFileLine      = regexp.MustCompile(`^\s+\S+\.go:\d+`)
+	variableParts   = regexp.MustCompile(`0x[0-9a-fA-F]+|\d+`)
+)
+
+// StackTrace is a stack trace found in the message
+type StackTrace struct {
+	Language string
+	// Type is the exception type, e.g. java.lang.IllegalStateException, or the panic message for Go
+	Type string
+	// Start and End are indices of the first line of the trace and the line following the trace
+	Start int
+	End   int
+	// exception is the index of the exception line
+	exception int
+	// headers are indices of the lines which are always displayed, such as the exception line
+	headers []int
+	// frames contains indices of the lines of each frame, innermost frame first
+	frames [][]int
+}
+
+// Fingerprint identifies the stack trace by its language, exception type and innermost frames.
+// Line numbers and addresses are ignored, so the same trace from different builds has the same fingerprint
+func (s *StackTrace) Fingerprint(lines []string) string {
+	h := sha1.New()
+	fmt.Fprintln(h, s.Language, s.Type)
+	for i, frame := range s.frames {
+		if i >= fingerprintFrames {
+			break
+		}
+		for _, line := range frame {
+			fmt.Fprintln(h, variableParts.ReplaceAllString(strings.TrimSpace(lines[line]), "#"))
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))[:8]
+}
+
+// visibleLines returns which lines of the trace are displayed when only the given number of innermost frames is shown
+func (s *StackTrace) visibleLines(frames int) map[int]bool {
+	visible := make(map[int]bool)
+	for _, line := range s.headers {
+		visible[line] = true
+	}
+	for i, frame := range s.frames {
+		if i >= frames {
+			break
+		}
+		for _, line := range frame {
+			visible[line] = true
+		}
+	}
+	return visible
+}
+
+func detectAtTrace(lines []string, start int) *StackTrace {
+	m := atExceptionLine.FindStringSubmatch(lines[start])
+	if m == nil || start+1 >= len(lines) || !atFrameLine.MatchString(lines[start+1]) {
+		return nil
+	}
+	trace := &StackTrace{Language: TraceNode, Type: m[1], Start: start, exception: start, headers: []int{start}}
+	if strings.Contains(m[1], ".") {
+		trace.Language = TraceJava
+	}
+	i := start + 1
+	for ; i < len(lines); i++ {
+		switch {
+		case atFrameLine.MatchString(lines[i]):
+			trace.frames = append(trace.frames, []int{i})
+		case atOtherLine.MatchString(lines[i]):
+			trace.headers = append(trace.headers, i)
+		default:
+			trace.End = i
+			return trace
+		}
+	}
+	trace.End = i
+	return trace
+}
+
+func detectPythonTrace(lines []string, start int) *StackTrace {
+	if !pythonStart.MatchString(lines[start]) {
+		return nil
+	}
+	trace := &StackTrace{Language: TracePython, Start: start, exception: start, headers: []int{start}}
+	var frames [][]int
+	i := start + 1
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		switch {
+		case pythonFrame.MatchString(line):
+			frames = append(frames, []int{i})
+		case len(frames) > 0 && strings.HasPrefix(line, " "):
+			// source code or marker lines belong to the frame above them
+			frames[len(frames)-1] = append(frames[len(frames)-1], i)
+		default:
+			if m := pythonException.FindStringSubmatch(line); m != nil {
+				trace.Type = m[1]
+				trace.exception = i
+				trace.headers = append(trace.headers, i)
+				i++
+			}
+			trace.End = i
+			// Python prints the innermost frame last
+			for j := len(frames) - 1; j >= 0; j-- {
+				trace.frames = append(trace.frames, frames[j])
+			}
+			return trace
+		}
+	}
+	return nil
+}
+
+func detectGoPanic(lines []string, start int) *StackTrace {
+	m := goPanicLine.FindStringSubmatch(lines[start])
+	if m == nil {
+		return nil
+	}
+	trace := &StackTrace{Language: TraceGo, Type: variableParts.ReplaceAllString(m[1], "#"), Start: start, exception: start, headers: []int{start}}
+	i := start + 1
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		switch {
+		case strings.TrimSpace(line) == "" || strings.HasPrefix(line, "[recovered]") || strings.HasPrefix(line, "\tpanic: "):
+			trace.headers = append(trace.headers, i)
+		case goGoroutineLine.MatchString(line):
+			trace.headers = append(trace.headers, i)
+		case goFileLine.MatchString(line) && len(trace.frames) > 0:
+			frame := trace.frames[len(trace.frames)-1]
+			trace.frames[len(trace.frames)-1] = append(frame, i)
+		case strings.Contains(line, "(") && !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t"):
+			trace.frames = append(trace.frames, []int{i})
+		default:
+			trace.End = i
+			return trace
+		}
+	}
+	trace.End = i
+	if len(trace.frames) == 0 {
+		return nil
+	}
+	return trace
+}
+
+// DetectStackTrace finds the first Java, Node, Python or Go stack trace in the lines of the message
+func DetectStackTrace(lines []string) *StackTrace {
+	for i := range lines {
+		for _, detect := range []func([]string, int) *StackTrace{detectAtTrace, detectPythonTrace, detectGoPanic} {
+			if trace := detect(lines, i); trace != nil {
+				return trace
+			}
+		}
+	}
+	return nil
+}
+
+// FoldedMessage is the message with folded stack trace. Notes contains start and end offsets of the notes added
+// to the message, such as the number of hidden frames
+type FoldedMessage struct {
+	Text  string
+	Notes [][]int
+}
+
+// TraceFolder folds stack traces to the exception line and a few innermost frames, and replaces the traces
+// which were already displayed with a note
+type TraceFolder struct {
+	frames int
+	seen   map[string]int
+}
+
+// NewTraceFolder creates a folder displaying the given number of innermost frames of each stack trace
+func NewTraceFolder(frames int) *TraceFolder {
+	return &TraceFolder{
+		frames: frames,
+		seen:   make(map[string]int),
+	}
+}
+
+type foldedBuilder struct {
+	sb    strings.Builder
+	notes [][]int
+}
+
+func (b *foldedBuilder) line(text string) {
+	if b.sb.Len() > 0 {
+		b.sb.WriteByte('\n')
+	}
+	b.sb.WriteString(text)
+}
+
+func (b *foldedBuilder) note(text string) {
+	b.line("")
+	start := b.sb.Len()
+	b.sb.WriteString(text)
+	b.notes = append(b.notes, []int{start, b.sb.Len()})
+}
+
+func moreLines(count int) string {
+	if count == 1 {
+		return "… 1 more line"
+	}
+	return fmt.Sprintf("… %d more lines", count)
+}
+
+func seenTimes(count int) string {
+	if count == 1 {
+		return "seen once"
+	}
+	return fmt.Sprintf("seen %d times", count)
+}
+
+// Fold folds the stack trace in the message, if there is one. Only traces of matched messages are counted,
+// so a trace is displayed in full the first time it matches, and messages displayed as context of other
+// messages don't change the count
+func (f *TraceFolder) Fold(message string, matched bool) FoldedMessage {
+	lines := strings.Split(message, "\n")
+	trace := DetectStackTrace(lines)
+	if trace == nil {
+		return FoldedMessage{Text: message}
+	}
+	fingerprint := trace.Fingerprint(lines)
+	count := f.seen[fingerprint]
+	if matched {
+		count++
+		f.seen[fingerprint] = count
+	}
+
+	var b foldedBuilder
+	for _, line := range lines[:trace.Start] {
+		b.line(line)
+	}
+	if count > 1 || (!matched && count > 0) {
+		b.line(lines[trace.exception])
+		b.note(fmt.Sprintf("… same %s stack trace %s, %s", trace.Language, fingerprint, seenTimes(count)))
+	} else {
+		visible := trace.visibleLines(f.frames)
+		hidden := 0
+		for i := trace.Start; i < trace.End; i++ {
+			if visible[i] {
+				if hidden > 0 {
+					b.note(moreLines(hidden))
+					hidden = 0
+				}
+				b.line(lines[i])
+			} else {
+				hidden++
+			}
+		}
+		if hidden > 0 {
+			b.note(moreLines(hidden))
+		}
+		b.note(fmt.Sprintf("… %s stack trace %s", trace.Language, fingerprint))
+	}
+	for _, line := range lines[trace.End:] {
+		b.line(line)
+	}
+	return FoldedMessage{Text: b.sb.String(), Notes: b.notes}
+}
+
+// ErrorFingerprint identifies the error reported by the message. Messages with stack traces are identified
+// by the fingerprint of the trace and labelled with the exception type, other messages of error or fatal level
+// by their first line with numbers and UUIDs masked. ok is false if the message doesn't report an error
+func ErrorFingerprint(message string, level Level) (fingerprint string, label string, ok bool) {
+	lines := strings.Split(message, "\n")
+	if trace := DetectStackTrace(lines); trace != nil {
+		label = trace.Type
+		if label == "" {
+			label = strings.TrimSpace(lines[trace.exception])
+		}
+		return trace.Fingerprint(lines), label, true
+	}
+	if level < LevelError {
+		return "", "", false
+	}
+	label = MaskVariableTokens(strings.TrimSpace(lines[0]))
+	h := sha1.Sum([]byte(label))
+	return hex.EncodeToString(h[:])[:8], label, true
+}
@@ -0,0 +1,164 @@
+package cwlogs
+
+import (
+	"strings"
+	"testing"
+)
+
+const javaTrace = `ERROR request failed
+java.lang.IllegalStateException: boom
+	at com.example.Service.run(Service.java:42)
+	at com.example.Handler.handle(Handler.java:17)
+	at com.example.Server.serve(Server.java:101)
+	at java.lang.Thread.run(Thread.java:748)
+Caused by: java.io.IOException: closed
+	at com.example.Stream.read(Stream.java:12)
+	... 4 more
+done`
+
+const pythonTrace = `Traceback (most recent call last):
+  File "app.py", line 10, in <module>
+    main()
+  File "app.py", line 6, in main
+    handle()
+  File "handler.py", line 3, in handle
+    raise ValueError("boom")
+ValueError: boom`
+
+const goPanic = `panic: runtime error: index out of range [5] with length 3
+
+goroutine 1 [running]:
+main.lookup(...)
+	/src/app/main.go:12 +0x1d
+main.main()
+	/src/app/main.go:7 +0x25
+exit status 2`
+
+const nodeTrace = `TypeError: Cannot read properties of undefined (reading 'id')
+    at getUser (/app/users.js:10:15)
+    at /app/server.js:22:5
+    at processTicksAndRejections (node:internal/process/task_queues:96:5)`
+
+func TestDetectStackTrace(t *testing.T) {
+	tests := []struct {
+		message  string
+		language string
+		typ      string
+		start    int
+		end      int
+		frames   int
+	}{
+		{javaTrace, TraceJava, "java.lang.IllegalStateException", 1, 9, 5},
+		{pythonTrace, TracePython, "ValueError", 0, 8, 3},
+		{goPanic, TraceGo, "runtime error: index out of range [#] with length #", 0, 7, 2},
+		{nodeTrace, TraceNode, "TypeError", 0, 4, 3},
+	}
+	for _, test := range tests {
+		trace := DetectStackTrace(strings.Split(test.message, "\n"))
+		if trace == nil {
+			t.Errorf("Expected %s stack trace to be detected", test.language)
+			continue
+		}
+		if trace.Language != test.language || trace.Type != test.typ || trace.Start != test.start ||
+			trace.End != test.end || len(trace.frames) != test.frames {
+			t.Errorf("Unexpected %s trace: %+v", test.language, trace)
+		}
+	}
+	if trace := DetectStackTrace([]string{"IllegalStateException: boom", "next line"}); trace != nil {
+		t.Errorf("Expected no stack trace without frames, got %+v", trace)
+	}
+}
+
+func TestStackTraceFingerprint(t *testing.T) {
+	lines := strings.Split(pythonTrace, "\n")
+	fingerprint := DetectStackTrace(lines).Fingerprint(lines)
+	// line numbers don't change the fingerprint
+	moved := strings.Split(strings.ReplaceAll(pythonTrace, "line 3", "line 4"), "\n")
+	if f := DetectStackTrace(moved).Fingerprint(moved); f != fingerprint {
+		t.Errorf("Expected the same fingerprint %s, got %s", fingerprint, f)
+	}
+	other := strings.Split(strings.ReplaceAll(pythonTrace, "in handle", "in process"), "\n")
+	if f := DetectStackTrace(other).Fingerprint(other); f == fingerprint {
+		t.Errorf("Expected different fingerprint for different frames")
+	}
+}
+
+func TestTraceFolder(t *testing.T) {
+	folder := NewTraceFolder(2)
+	lines := strings.Split(javaTrace, "\n")
+	fingerprint := DetectStackTrace(lines).Fingerprint(lines)
+	folded := folder.Fold(javaTrace, true)
+	expected := `ERROR request failed
+java.lang.IllegalStateException: boom
+	at com.example.Service.run(Service.java:42)
+	at com.example.Handler.handle(Handler.java:17)
+… 2 more lines
+Caused by: java.io.IOException: closed
+… 1 more line
+	... 4 more
+… java stack trace ` + fingerprint + `
+done`
+	if folded.Text != expected {
+		t.Errorf("\nExpected: %q\n  Actual: %q", expected, folded.Text)
+	}
+	if len(folded.Notes) != 3 || folded.Text[folded.Notes[0][0]:folded.Notes[0][1]] != "… 2 more lines" {
+		t.Errorf("Unexpected notes %v", folded.Notes)
+	}
+
+	folder.Fold(javaTrace, true)
+	repeated := folder.Fold(javaTrace, true)
+	expected = `ERROR request failed
+java.lang.IllegalStateException: boom
+… same java stack trace ` + fingerprint + `, seen 3 times
+done`
+	if repeated.Text != expected {
+		t.Errorf("\nExpected: %q\n  Actual: %q", expected, repeated.Text)
+	}
+
+	if plain := folder.Fold("INFO started", true); plain.Text != "INFO started" || plain.Notes != nil {
+		t.Errorf("Expected message without stack trace to be unchanged, got %+v", plain)
+	}
+}
+
+func TestTraceFolderCountsOnlyMatchedTraces(t *testing.T) {
+	folder := NewTraceFolder(2)
+	lines := strings.Split(javaTrace, "\n")
+	fingerprint := DetectStackTrace(lines).Fingerprint(lines)
+	full := NewTraceFolder(2).Fold(javaTrace, true).Text
+
+	// the trace which was filtered out, but displayed as context, doesn't hide the frames of the matching one
+	if context := folder.Fold(javaTrace, false); context.Text != full {
+		t.Errorf("\nExpected: %q\n  Actual: %q", full, context.Text)
+	}
+	if first := folder.Fold(javaTrace, true); first.Text != full {
+		t.Errorf("\nExpected: %q\n  Actual: %q", full, first.Text)
+	}
+	expected := `ERROR request failed
+java.lang.IllegalStateException: boom
+… same java stack trace ` + fingerprint + `, seen once
+done`
+	if context := folder.Fold(javaTrace, false); context.Text != expected {
+		t.Errorf("\nExpected: %q\n  Actual: %q", expected, context.Text)
+	}
+	expected = strings.Replace(expected, "seen once", "seen 2 times", 1)
+	if second := folder.Fold(javaTrace, true); second.Text != expected {
+		t.Errorf("\nExpected: %q\n  Actual: %q", expected, second.Text)
+	}
+}
+
+func TestErrorFingerprint(t *testing.T) {
+	fingerprint, label, ok := ErrorFingerprint(nodeTrace, LevelUnknown)
+	if !ok || label != "TypeError" || len(fingerprint) != 8 {
+		t.Errorf("Unexpected fingerprint %s of %s", fingerprint, label)
+	}
+	first, label, ok := ErrorFingerprint("ERROR connection 12 refused\ndetails", LevelError)
+	if !ok || label != "ERROR connection # refused" {
+		t.Errorf("Unexpected label %s", label)
+	}
+	if second, _, _ := ErrorFingerprint("ERROR connection 17 refused", LevelError); second != first {
+		t.Errorf("Expected the same fingerprint %s, got %s", first, second)
+	}
+	if _, _, ok = ErrorFingerprint("INFO started", LevelInfo); ok {
+		t.Errorf("Expected no fingerprint for info message")
+	}
+}
@@ -0,0 +1,78 @@
+package cwlogs
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/aws/smithy-go"
+)
+
+// APIStats contains the number of Cloudwatch Logs API calls made, the amount of data received and the state
+// of the last call
+type APIStats struct {
+	Calls  int64
+	Errors int64
+	Events int64
+	// Bytes is the total size of received event messages
+	Bytes int64
+	// Streams is the number of streams events are read from
+	Streams int
+	// LastError is the error of the last call, empty if it succeeded
+	LastError string
+	// Throttled is true if the last call was rejected because of the request rate
+	Throttled bool
+}
+
+var (
+	apiStats     APIStats
+	apiStatsLock sync.Mutex
+)
+
+// isThrottling returns true if the error is caused by exceeding the request rate
+func isThrottling(err error) bool {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		code := apiErr.ErrorCode()
+		return code == "ThrottlingException" || code == "Throttling" || code == "TooManyRequestsException"
+	}
+	return false
+}
+
+// countCall records an API call, which returned the messages or failed if err is not nil
+func countCall(err error, messages ...*string) {
+	apiStatsLock.Lock()
+	defer apiStatsLock.Unlock()
+	apiStats.Calls++
+	if err != nil {
+		apiStats.Errors++
+		apiStats.LastError = err.Error()
+		apiStats.Throttled = isThrottling(err)
+		return
+	}
+	apiStats.LastError = ""
+	apiStats.Throttled = false
+	for _, m := range messages {
+		if m != nil {
+			apiStats.Bytes += int64(len(*m))
+		}
+	}
+	apiStats.Events += int64(len(messages))
+}
+
+// countStreams records the number of streams events are read from
+func countStreams(streams []logStream) {
+	apiStatsLock.Lock()
+	defer apiStatsLock.Unlock()
+	apiStats.Streams = 0
+	for _, s := range streams {
+		apiStats.Streams += len(s.streamNames)
+	}
+}
+
+// GetAPIStats returns the number of API calls made since cwltail started, the amount of data received
+// and the state of the last call
+func GetAPIStats() APIStats {
+	apiStatsLock.Lock()
+	defer apiStatsLock.Unlock()
+	return apiStats
+}
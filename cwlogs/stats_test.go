@@ -0,0 +1,31 @@
+package cwlogs
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/smithy-go"
+)
+
+func TestCountCall(t *testing.T) {
+	before := GetAPIStats()
+	message := "hello"
+	countCall(nil, &message, &message)
+	countCall(&smithy.GenericAPIError{Code: "ThrottlingException", Message: "Rate exceeded"})
+	stats := GetAPIStats()
+	if stats.Calls-before.Calls != 2 || stats.Errors-before.Errors != 1 || stats.Events-before.Events != 2 ||
+		stats.Bytes-before.Bytes != 10 {
+		t.Errorf("Unexpected stats %+v, before %+v", stats, before)
+	}
+	if !stats.Throttled || stats.LastError == "" {
+		t.Errorf("Expected throttling error, got %+v", stats)
+	}
+	countCall(errors.New("connection reset"))
+	if stats = GetAPIStats(); stats.Throttled || stats.LastError != "connection reset" {
+		t.Errorf("Expected non-throttling error, got %+v", stats)
+	}
+	countCall(nil)
+	if stats = GetAPIStats(); stats.LastError != "" {
+		t.Errorf("Expected error to be cleared, got %+v", stats)
+	}
+}
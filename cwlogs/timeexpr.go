@@ -0,0 +1,146 @@
+package cwlogs
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	relativeRe = regexp.MustCompile(`^(\d+(?:\.\d+)?)\s*([a-z]+)(?:\s+ago)?$`)
+	epochRe    = regexp.MustCompile(`^\d+$`)
+	dayRe      = regexp.MustCompile(`^(today|yesterday)(?:\s+(.+))?$`)
+
+	relativeUnits = map[string]time.Duration{
+		"ms":      time.Millisecond,
+		"s":       time.Second,
+		"sec":     time.Second,
+		"secs":    time.Second,
+		"second":  time.Second,
+		"seconds": time.Second,
+		"m":       time.Minute,
+		"min":     time.Minute,
+		"mins":    time.Minute,
+		"minute":  time.Minute,
+		"minutes": time.Minute,
+		"h":       time.Hour,
+		"hr":      time.Hour,
+		"hrs":     time.Hour,
+		"hour":    time.Hour,
+		"hours":   time.Hour,
+		"d":       24 * time.Hour,
+		"day":     24 * time.Hour,
+		"days":    24 * time.Hour,
+		"w":       7 * 24 * time.Hour,
+		"week":    7 * 24 * time.Hour,
+		"weeks":   7 * 24 * time.Hour,
+	}
+
+	// layouts of absolute timestamps without time zone, interpreted in the requested location
+	absoluteLayouts = []string{
+		"2006-01-02T15:04:05.000",
+		"2006-01-02T15:04:05",
+		"2006-01-02T15:04",
+		"2006-01-02 15:04:05.000",
+		"2006-01-02 15:04:05",
+		"2006-01-02 15:04",
+		"2006-01-02",
+	}
+
+	// layouts of time of day, used on their own or after today/yesterday
+	timeOfDayLayouts = []string{
+		"15:04:05.000",
+		"15:04:05",
+		"15:04",
+	}
+)
+
+// ParseLocation returns time zone location by name. Empty name and "local" mean the local time zone
+func ParseLocation(name string) (*time.Location, error) {
+	switch strings.ToLower(name) {
+	case "", "local":
+		return time.Local, nil
+	case "utc":
+		return time.UTC, nil
+	}
+	return time.LoadLocation(name)
+}
+
+func parseTimeOfDay(expr string) (time.Time, bool) {
+	for _, layout := range timeOfDayLayouts {
+		if tm, err := time.Parse(layout, expr); err == nil {
+			return tm, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func atTimeOfDay(day time.Time, tod time.Time, loc *time.Location) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), tod.Hour(), tod.Minute(), tod.Second(), tod.Nanosecond(), loc)
+}
+
+// ParseTimeExpression parses human-friendly time expression relative to now. Supported expressions are
+//   - "now"
+//   - durations, optionally followed by "ago": "15m", "2h ago", "3 days ago", "1h30m"
+//   - "today" and "yesterday", optionally followed by time of day: "yesterday 14:00"
+//   - time of day, meaning today: "14:00", "14:00:05"
+//   - date and time: "2026-10-16T10:00", "2026-10-16 10:00:00", "2026-10-16"
+//   - RFC3339 timestamp with time zone: "2026-10-16T10:00:00+02:00"
+//   - epoch milliseconds: "1760608800000"
+//
+// Expressions without explicit time zone are interpreted in the loc location
+func ParseTimeExpression(expr string, now time.Time, loc *time.Location) (time.Time, error) {
+	e := strings.ToLower(strings.TrimSpace(expr))
+	if e == "" {
+		return time.Time{}, fmt.Errorf("empty time expression")
+	}
+	now = now.In(loc)
+
+	if e == "now" {
+		return now, nil
+	}
+	if epochRe.MatchString(e) {
+		ts, err := strconv.ParseInt(e, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid epoch timestamp '%s': %v", expr, err)
+		}
+		return AwsToTime(ts).In(loc), nil
+	}
+	if m := relativeRe.FindStringSubmatch(e); m != nil {
+		if unit, ok := relativeUnits[m[2]]; ok {
+			value, _ := strconv.ParseFloat(m[1], 64)
+			return now.Add(-time.Duration(value * float64(unit))), nil
+		}
+	}
+	if d, err := time.ParseDuration(strings.TrimSuffix(e, " ago")); err == nil {
+		return now.Add(-d), nil
+	}
+	if m := dayRe.FindStringSubmatch(e); m != nil {
+		day := now
+		if m[1] == "yesterday" {
+			day = now.AddDate(0, 0, -1)
+		}
+		if m[2] == "" {
+			return time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, loc), nil
+		}
+		tod, ok := parseTimeOfDay(m[2])
+		if !ok {
+			return time.Time{}, fmt.Errorf("invalid time of day '%s' in '%s'", m[2], expr)
+		}
+		return atTimeOfDay(day, tod, loc), nil
+	}
+	if tod, ok := parseTimeOfDay(e); ok {
+		return atTimeOfDay(now, tod, loc), nil
+	}
+	if tm, err := time.Parse(time.RFC3339Nano, strings.ToUpper(e)); err == nil {
+		return tm, nil
+	}
+	for _, layout := range absoluteLayouts {
+		if tm, err := time.ParseInLocation(layout, strings.ToUpper(e), loc); err == nil {
+			return tm, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized time expression '%s'", expr)
+}
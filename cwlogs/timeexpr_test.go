@@ -0,0 +1,116 @@
+package cwlogs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimeExpression(t *testing.T) {
+	loc := time.FixedZone("TEST", 2*3600)
+	now := time.Date(2026, 10, 17, 12, 30, 15, 0, loc)
+
+	tests := []struct {
+		expr     string
+		expected time.Time
+	}{
+		{"now", now},
+		{"15m", now.Add(-15 * time.Minute)},
+		{"2h ago", now.Add(-2 * time.Hour)},
+		{"3 days ago", now.Add(-72 * time.Hour)},
+		{"1w", now.Add(-7 * 24 * time.Hour)},
+		{"90 seconds ago", now.Add(-90 * time.Second)},
+		{"1.5h", now.Add(-90 * time.Minute)},
+		{"1h30m", now.Add(-90 * time.Minute)},
+		{"1h30m ago", now.Add(-90 * time.Minute)},
+		{"today", time.Date(2026, 10, 17, 0, 0, 0, 0, loc)},
+		{"Today 08:15", time.Date(2026, 10, 17, 8, 15, 0, 0, loc)},
+		{"yesterday", time.Date(2026, 10, 16, 0, 0, 0, 0, loc)},
+		{"yesterday 14:00", time.Date(2026, 10, 16, 14, 0, 0, 0, loc)},
+		{"yesterday 14:00:05.250", time.Date(2026, 10, 16, 14, 0, 5, 250000000, loc)},
+		{"10:45", time.Date(2026, 10, 17, 10, 45, 0, 0, loc)},
+		{"10:45:30", time.Date(2026, 10, 17, 10, 45, 30, 0, loc)},
+		{"2026-10-16T10:00", time.Date(2026, 10, 16, 10, 0, 0, 0, loc)},
+		{"2026-10-16t10:00:01", time.Date(2026, 10, 16, 10, 0, 1, 0, loc)},
+		{"2026-10-16 10:00:01.500", time.Date(2026, 10, 16, 10, 0, 1, 500000000, loc)},
+		{"2026-10-16", time.Date(2026, 10, 16, 0, 0, 0, 0, loc)},
+		{"2026-10-16T10:00:00Z", time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)},
+		{"2026-10-16T10:00:00-05:00", time.Date(2026, 10, 16, 15, 0, 0, 0, time.UTC)},
+		{"1760608800123", time.Date(2025, 10, 16, 10, 0, 0, 123000000, time.UTC)},
+		{"  2h  ", now.Add(-2 * time.Hour)},
+	}
+
+	for _, test := range tests {
+		actual, err := ParseTimeExpression(test.expr, now, loc)
+		if err != nil {
+			t.Errorf("'%s': unexpected error %v", test.expr, err)
+			continue
+		}
+		if !actual.Equal(test.expected) {
+			t.Errorf("'%s'\nExpected: %v\n  Actual: %v", test.expr, test.expected, actual)
+		}
+	}
+}
+
+func TestParseTimeExpressionUsesLocation(t *testing.T) {
+	now := time.Date(2026, 10, 17, 1, 0, 0, 0, time.UTC)
+	loc := time.FixedZone("WEST", -5*3600)
+	// it is still October 16th in the requested time zone
+	actual, err := ParseTimeExpression("today", now, loc)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	expected := time.Date(2026, 10, 16, 0, 0, 0, 0, loc)
+	if !actual.Equal(expected) {
+		t.Errorf("\nExpected: %v\n  Actual: %v", expected, actual)
+	}
+}
+
+func TestParseTimeExpressionErrors(t *testing.T) {
+	now := time.Now()
+	tests := []string{
+		"",
+		"tomorrow",
+		"15 parsecs ago",
+		"yesterday noon",
+		"2026-13-40",
+		"25:00",
+	}
+
+	for _, expr := range tests {
+		if tm, err := ParseTimeExpression(expr, now, time.UTC); err == nil {
+			t.Errorf("'%s': expected error, got %v", expr, tm)
+		}
+	}
+}
+
+func TestParseLocation(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"", "Local"},
+		{"local", "Local"},
+		{"UTC", "UTC"},
+		{"utc", "UTC"},
+	}
+	for _, test := range tests {
+		loc, err := ParseLocation(test.name)
+		if err != nil {
+			t.Errorf("'%s': unexpected error %v", test.name, err)
+			continue
+		}
+		if loc.String() != test.expected {
+			t.Errorf("'%s': expected %s, got %s", test.name, test.expected, loc.String())
+		}
+	}
+	if _, err := ParseLocation("Not/AZone"); err == nil {
+		t.Errorf("Expected error for unknown time zone")
+	}
+}
+
+func TestAwsTimeConversionKeepsMilliseconds(t *testing.T) {
+	ts := int64(1760608800123)
+	if TimeToAws(AwsToTime(ts)) != ts {
+		t.Errorf("Expected %d, got %d", ts, TimeToAws(AwsToTime(ts)))
+	}
+}
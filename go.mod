@@ -8,7 +8,10 @@ require (
 	github.com/aws/aws-sdk-go-v2/config v1.1.1
 	github.com/aws/aws-sdk-go-v2/credentials v1.1.1
 	github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs v1.1.1
-	github.com/dlclark/regexp2 v1.4.0 // indirect
+	github.com/aws/smithy-go v1.1.0
+	github.com/dlclark/regexp2 v1.4.0
 	github.com/sirupsen/logrus v1.8.0
 	golang.org/x/sys v0.0.0-20210226181700-f36f78243c0c // indirect
+	golang.org/x/term v0.0.0-20210220032956-6a3ed077a48d
+	gopkg.in/yaml.v2 v2.4.0
 )
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/uaraven/cwltail/config"
+	"github.com/uaraven/cwltail/cwlogs"
+)
+
+const listTimeFormat = "2006-01-02 15:04:05"
+
+type groupsCommand struct {
+	awsOptions
+	Output string `arg:"-o,--output" help:"Output format, table or json" default:"table"`
+	Prefix string `arg:"positional" help:"Log group name prefix"`
+}
+
+type streamsCommand struct {
+	awsOptions
+	Output   string `arg:"-o,--output" help:"Output format, table or json" default:"table"`
+	LogGroup string `arg:"positional,required" help:"Log group name"`
+}
+
+// parseCommand parses arguments of a subcommand into dest, using values from the configuration file
+// for options which are not passed on the command line
+func parseCommand(name string, args []string, dest interface{}) {
+	parseArgs("cwltail "+name, args, dest)
+	values := loadConfig("")
+	if err := config.Apply(dest, values, args, false); err != nil {
+		fmt.Printf("Invalid configuration: %v\n", err)
+		os.Exit(-1)
+	}
+}
+
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Format(listTimeFormat)
+}
+
+func formatRetention(days int32) string {
+	if days == 0 {
+		return "never expire"
+	}
+	return fmt.Sprintf("%d days", days)
+}
+
+func writeJSON(w io.Writer, value interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+// writeGroups writes the log groups as a table or JSON array
+func writeGroups(w io.Writer, groups []cwlogs.LogGroupInfo, format string) error {
+	if format == "json" {
+		return writeJSON(w, groups)
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tSTORED BYTES\tRETENTION\tLAST EVENT")
+	for _, g := range groups {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\n", g.Name, g.StoredBytes, formatRetention(g.RetentionInDays), formatOptionalTime(g.LastEventTime))
+	}
+	return tw.Flush()
+}
+
+// writeStreams writes the log streams as a table or JSON array
+func writeStreams(w io.Writer, streams []cwlogs.LogStreamInfo, format string) error {
+	if format == "json" {
+		return writeJSON(w, streams)
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tFIRST EVENT\tLAST EVENT")
+	for _, s := range streams {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", s.Name, formatOptionalTime(s.FirstEventTime), formatOptionalTime(s.LastEventTime))
+	}
+	return tw.Flush()
+}
+
+func checkOutputFormat(format string) {
+	if format != "table" && format != "json" {
+		fmt.Printf("Unsupported output format '%s', use table or json\n", format)
+		os.Exit(-1)
+	}
+}
+
+func listGroups(args []string) {
+	var cmd groupsCommand
+	parseCommand("groups", args, &cmd)
+	checkOutputFormat(cmd.Output)
+
+	groups, err := cwlogs.ListGroups(cmd.createClient(), cmd.Prefix)
+	if err != nil {
+		fmt.Printf("Failed to list log groups: %v\n", err)
+		os.Exit(-1)
+	}
+	if err = writeGroups(os.Stdout, groups, cmd.Output); err != nil {
+		fmt.Printf("Failed to write log groups: %v\n", err)
+		os.Exit(-1)
+	}
+}
+
+func listStreams(args []string) {
+	var cmd streamsCommand
+	parseCommand("streams", args, &cmd)
+	checkOutputFormat(cmd.Output)
+
+	streams, err := cwlogs.ListStreams(cmd.createClient(), cmd.LogGroup)
+	if err != nil {
+		fmt.Printf("Failed to list log streams: %v\n", err)
+		os.Exit(-1)
+	}
+	if err = writeStreams(os.Stdout, streams, cmd.Output); err != nil {
+		fmt.Printf("Failed to write log streams: %v\n", err)
+		os.Exit(-1)
+	}
+}
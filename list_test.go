@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/uaraven/cwltail/cwlogs"
+)
+
+func TestWriteGroups(t *testing.T) {
+	last := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+	groups := []cwlogs.LogGroupInfo{
+		{Name: "/aws/lambda/api", StoredBytes: 1024, RetentionInDays: 30, CreationTime: last, LastEventTime: &last},
+		{Name: "/ecs/worker", StoredBytes: 0, CreationTime: last},
+	}
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{"table", "NAME             STORED BYTES  RETENTION     LAST EVENT\n" +
+			"/aws/lambda/api  1024          30 days       2026-10-19 10:00:00\n" +
+			"/ecs/worker      0             never expire  -\n"},
+		{"json", `[
+  {
+    "name": "/aws/lambda/api",
+    "storedBytes": 1024,
+    "retentionInDays": 30,
+    "creationTime": "2026-10-19T10:00:00Z",
+    "lastEventTime": "2026-10-19T10:00:00Z"
+  },
+  {
+    "name": "/ecs/worker",
+    "storedBytes": 0,
+    "creationTime": "2026-10-19T10:00:00Z"
+  }
+]
+`},
+	}
+	for _, test := range tests {
+		var out bytes.Buffer
+		if err := writeGroups(&out, groups, test.format); err != nil {
+			t.Fatalf("%s: unexpected error %v", test.format, err)
+		}
+		if out.String() != test.expected {
+			t.Errorf("%s\nExpected: %q\n  Actual: %q", test.format, test.expected, out.String())
+		}
+	}
+}
+
+func TestWriteStreams(t *testing.T) {
+	first := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	last := first.Add(time.Hour)
+	streams := []cwlogs.LogStreamInfo{
+		{Name: "2026/10/19/[$LATEST]abc", StoredBytes: 10, FirstEventTime: &first, LastEventTime: &last},
+		{Name: "empty"},
+	}
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{"table", "NAME                     FIRST EVENT          LAST EVENT\n" +
+			"2026/10/19/[$LATEST]abc  2026-10-19 09:00:00  2026-10-19 10:00:00\n" +
+			"empty                    -                    -\n"},
+		{"json", `[
+  {
+    "name": "2026/10/19/[$LATEST]abc",
+    "storedBytes": 10,
+    "firstEventTime": "2026-10-19T09:00:00Z",
+    "lastEventTime": "2026-10-19T10:00:00Z"
+  },
+  {
+    "name": "empty",
+    "storedBytes": 0
+  }
+]
+`},
+	}
+	for _, test := range tests {
+		var out bytes.Buffer
+		if err := writeStreams(&out, streams, test.format); err != nil {
+			t.Fatalf("%s: unexpected error %v", test.format, err)
+		}
+		if out.String() != test.expected {
+			t.Errorf("%s\nExpected: %q\n  Actual: %q", test.format, test.expected, out.String())
+		}
+	}
+}
@@ -3,8 +3,11 @@ package main
 import (
 	"fmt"
 	"os"
+	"os/signal"
 	"regexp"
+	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/alexflint/go-arg"
@@ -12,7 +15,9 @@ import (
 
 	log "github.com/sirupsen/logrus"
 	"github.com/uaraven/cwltail/awsi"
+	"github.com/uaraven/cwltail/config"
 	"github.com/uaraven/cwltail/cwlogs"
+	"github.com/uaraven/cwltail/query"
 	"github.com/uaraven/cwltail/ui"
 )
 
@@ -20,83 +25,508 @@ type logCollectionContext struct {
 	LogGroup           string
 	HighlightPattern   *regexp.Regexp
 	LevelDetectPattern *regexp.Regexp
-	FilterPattern      *regexp.Regexp
-	InvertFilter       bool
+	TextFilter         *cwlogs.TextFilter
+	ContextLines       *ui.ContextLines
+	Traces             *cwlogs.TraceFolder
+	TimestampFormatter *ui.TimestampFormatter
+	Theme              *ui.Theme
+	Highlights         *ui.Theme
+	Formats            *ui.FormatDetector
+	LevelStyles        ui.LevelStyles
+	LevelField         []string
+	TimeField          []string
+	JSONRenderer       *ui.JSONRenderer
+	Selection          *query.Selection
+	Where              *query.Filter
+	ExtractPattern     *regexp.Regexp
+	Location           *time.Location
+	LevelFilter        *cwlogs.LevelFilter
 	Events             chan cwlogs.CWLEvent
+	Interrupted        chan os.Signal
+	Gap                time.Duration
+	Heartbeat          time.Duration
+	Summary            *summary
 	StartTime          time.Time
 	EndTime            *time.Time
 }
 
-func createLogLine(context *logCollectionContext, event cwlogs.CWLEvent) *string {
+// createLogLine formats the event for display and checks if it passes the filters. Lines of the events
+// which don't pass the filters are only created when they can be displayed as context, otherwise nil is returned
+func createLogLine(context *logCollectionContext, event cwlogs.CWLEvent) (*ui.Line, bool) {
+	matched := true
+	keepUnmatched := context.ContextLines != nil
 	streamID := event.ShortStreamName()
-	var logLine string
-	if context.FilterPattern != nil {
-		match := context.FilterPattern.FindStringIndex(event.Message())
-		if match == nil {
-			if !context.InvertFilter {
-				return nil
+	timestamp := event.Timestamp()
+	// the message is parsed once, its fields are used for level detection, rendering and expressions
+	var fields, jsonMessage *query.Object
+	if context.JSONRenderer != nil || context.TimeField != nil || context.LevelField != nil ||
+		context.Where != nil || context.Selection != nil {
+		var format string
+		if fields, format = query.ParseFields(event.Message()); format == query.FormatJSON {
+			jsonMessage = fields
+		}
+	}
+	var levelToken []int
+	level := cwlogs.LevelUnknown
+	if context.LevelField != nil {
+		level = cwlogs.DetectLevelFromFields(jsonMessage, context.LevelField)
+	}
+	if jsonMessage != nil && context.TimeField != nil {
+		if value, ok := jsonMessage.Lookup(context.TimeField); ok {
+			if tm, ok := ui.JSONTimestamp(value); ok {
+				timestamp = tm
 			}
-			logLine = event.Message()
-		} else {
-			if context.InvertFilter {
-				return nil
+		}
+	}
+	if level == cwlogs.LevelUnknown && context.LevelDetectPattern != nil {
+		level, levelToken = cwlogs.DetectLevel(context.LevelDetectPattern, event.Message())
+	}
+	event.SetLevel(level)
+	if context.LevelFilter != nil && !context.LevelFilter.Accept(level) {
+		if !keepUnmatched {
+			return nil, false
+		}
+		matched = false
+	}
+	var record *query.Record
+	if context.Where != nil || context.Selection != nil {
+		record = createRecord(context, event, timestamp, fields)
+	}
+	if matched && context.Where != nil && !context.Where.Match(record) {
+		if !keepUnmatched {
+			return nil, false
+		}
+		matched = false
+	}
+	// filters see the original message, matches are highlighted in the displayed text later
+	if matched && context.TextFilter != nil {
+		if !context.TextFilter.Match(event.Message()) {
+			if !keepUnmatched {
+				return nil, false
 			}
-			logLine = ui.HighlightSelection(event.Message(), match, ":cyan")
+			matched = false
+		}
+	}
+	// rendered JSON messages are already highlighted, everything else works with the displayed text
+	var message *ui.StyledText
+	var notes [][]int
+	if context.Selection != nil && (record.Fields != nil || !context.Selection.UsesFields()) {
+		message = ui.RenderColumns(context.Selection.Evaluate(record))
+	} else if jsonMessage != nil && context.JSONRenderer != nil {
+		message = context.JSONRenderer.Render(jsonMessage)
+	}
+	rendered := message != nil
+	if rendered {
+		if levelToken != nil {
+			_, levelToken = cwlogs.DetectLevel(context.LevelDetectPattern, message.Text())
 		}
+	} else if context.Traces != nil {
+		folded := context.Traces.Fold(event.Message(), matched)
+		message = ui.NewStyledText(folded.Text)
+		notes = folded.Notes
 	} else {
-		logLine = event.Message()
+		message = ui.NewStyledText(event.Message())
+	}
+	text := message.Text()
+	if matched && context.TextFilter != nil {
+		for _, match := range context.TextFilter.Highlights(text) {
+			message.AddStyle(match[0], match[1], ":cyan", ui.PriorityFilter)
+		}
 	}
-	if !options.NoHighlighting {
+	if !options.NoHighlighting && !rendered {
 		if context.HighlightPattern != nil {
-			logLine = ui.Colorize(context.HighlightPattern, logLine)
+			ui.ColorizeText(context.HighlightPattern, message, ui.PriorityTheme)
 		} else {
-			logLine = ui.ColorizeByColorName(logLine)
+			theme := ui.FormatTheme(context.Formats.Format(streamKey(event), text))
+			if theme == nil {
+				theme = context.Theme
+			}
+			theme.Apply(message, ui.PriorityTheme)
 		}
 	}
+	context.Highlights.Apply(message, ui.PriorityHighlight)
+	for _, note := range notes {
+		message.AddStyle(note[0], note[1], ui.NoteStyle, ui.PriorityPrefix)
+	}
 	if options.LevelHighlight {
-		if context.LevelDetectPattern != nil {
-			matches := context.LevelDetectPattern.FindStringSubmatch(event.Message())
-			if len(matches) > 0 {
-				logLine = ui.HighlightLogLevel(context.LevelDetectPattern.SubexpNames()[1:], matches[1:], logLine)
+		context.LevelStyles.Apply(message, event.Level().String(), levelToken)
+	}
+	if options.OneLine {
+		message.ReplaceNewlines(ui.EscapedNewline, ui.GutterStyle)
+	}
+	return &ui.Line{Stream: streamID, Timestamp: timestamp, Message: message}, matched
+}
+
+// linePrefix creates the prefix of the displayed line with stream name and timestamp, if they are enabled.
+// It is called when the line is printed, so relative timestamps are calculated between printed lines
+func linePrefix(context *logCollectionContext, streamID string, timestamp time.Time) *ui.StyledText {
+	line := ui.NewStyledText("")
+	if options.ShowStreamNames {
+		line.Append("[", "").Append(streamID, ui.StreamNameStyle).Append("] ", "")
+	}
+	if context.TimestampFormatter != nil {
+		line.Append("[", "").Append(context.TimestampFormatter.Format(timestamp), ui.TimestampStyle).Append("] ", "")
+	}
+	return line
+}
+
+// createNoteLine creates the line of the note about repeated messages
+func createNoteLine(note *cwlogs.RepeatNote) *ui.Line {
+	message := ui.NewStyledText("").Append(note.Message(), ui.NoteStyle)
+	return &ui.Line{Stream: note.ShortStreamName(), Timestamp: note.Timestamp(), Message: message}
+}
+
+// createRecord creates the event representation for --where and --select expressions. Fields are parsed from
+// JSON or logfmt messages and extracted with --extract pattern
+func createRecord(context *logCollectionContext, event cwlogs.CWLEvent, timestamp time.Time, fields *query.Object) *query.Record {
+	record := &query.Record{
+		Fields:    fields,
+		Message:   event.Message(),
+		Timestamp: timestamp.In(context.Location),
+		Group:     event.LogGroup(),
+		Stream:    event.LogStream(),
+		Level:     event.Level().String(),
+	}
+	if context.ExtractPattern != nil {
+		if extracted := query.ExtractFields(context.ExtractPattern, event.Message()); extracted != nil {
+			if fields == nil {
+				record.Fields = extracted
+			} else {
+				// the parsed fields are also rendered, so they are not modified
+				record.Fields = fields.Merge(extracted)
 			}
 		}
 	}
-	if options.ShowEventTime || options.ShowEventTimestamp {
-		var format string
-		if options.ShowEventTime {
-			format = "15:04:05.000"
+	return record
+}
+
+// streamKey identifies the stream of the event among all the log groups
+func streamKey(event cwlogs.CWLEvent) string {
+	return event.LogGroup() + "/" + event.LogStream()
+}
+
+func collectAndDisplay(wg *sync.WaitGroup, context *logCollectionContext) {
+	defer wg.Done()
+	// whether the last event of each stream was displayed, notes about repeated messages are displayed only
+	// if the repeated message was
+	displayed := make(map[string]bool)
+	writeLine := func(line string) {
+		fmt.Println(line)
+	}
+	var status *ui.StatusLine
+	var tracker *statusTracker
+	var refresh <-chan time.Time
+	if options.Status && ui.StdoutIsTerminal() {
+		status = ui.NewStatusLine(os.Stdout, 0)
+		defer status.Clear()
+		writeLine = status.Println
+		tracker = newStatusTracker(time.Now())
+		ticker := time.NewTicker(statusRefresh)
+		defer ticker.Stop()
+		refresh = ticker.C
+	}
+	// heartbeat timer is restarted with every printed line, so it fires only after the whole period of silence
+	var heartbeat *time.Timer
+	var silence <-chan time.Time
+	if context.Heartbeat > 0 {
+		heartbeat = time.NewTimer(context.Heartbeat)
+		defer heartbeat.Stop()
+		silence = heartbeat.C
+	}
+	lastPrinted := time.Now()
+	printLine := func(line string) {
+		lastPrinted = time.Now()
+		writeLine(line)
+		if heartbeat != nil {
+			if !heartbeat.Stop() {
+				select {
+				case <-heartbeat.C:
+				default:
+				}
+			}
+			heartbeat.Reset(context.Heartbeat)
+		}
+	}
+	// gap separator is printed in front of the first line after the gap, which may be a context line
+	var lastDisplayed time.Time
+	printEventLine := func(line *ui.Line) {
+		if context.Gap > 0 && !line.Separator {
+			if gap := line.Timestamp.Sub(lastDisplayed); !lastDisplayed.IsZero() && gap > context.Gap {
+				width, _ := ui.TerminalSize()
+				printLine(ui.Separator(fmt.Sprintf("%s without events", gap.Round(time.Second)), width).Render())
+			}
+			if line.Timestamp.After(lastDisplayed) {
+				lastDisplayed = line.Timestamp
+			}
+		}
+		printLine(line.Styled(linePrefix(context, line.Stream, line.Timestamp)).Render())
+	}
+	for {
+		var event cwlogs.CWLEvent
+		select {
+		case e, ok := <-context.Events:
+			if !ok {
+				return
+			}
+			event = e
+		case <-context.Interrupted:
+			return
+		case now := <-refresh:
+			width, _ := ui.TerminalSize()
+			status.Update(tracker.render(now, cwlogs.GetAPIStats()), width)
+			continue
+		case now := <-silence:
+			width, _ := ui.TerminalSize()
+			label := fmt.Sprintf("no events for %s, still tailing at %s", now.Sub(lastPrinted).Round(time.Second),
+				now.In(context.Location).Format("15:04:05"))
+			writeLine(ui.Separator(label, width).Render())
+			heartbeat.Reset(context.Heartbeat)
+			continue
+		}
+		if tracker != nil {
+			tracker.add(event, time.Now())
+		}
+		var logLine *ui.Line
+		var matched bool
+		if note, isNote := event.(*cwlogs.RepeatNote); isNote {
+			logLine, matched = createNoteLine(note), displayed[streamKey(event)]
 		} else {
-			format = time.RFC3339
+			logLine, matched = createLogLine(context, event)
+			displayed[streamKey(event)] = matched
+		}
+		context.Summary.add(event, matched)
+		if context.ContextLines != nil {
+			for _, line := range context.ContextLines.Add(streamKey(event), logLine, matched) {
+				printEventLine(line)
+			}
+		} else if matched {
+			printEventLine(logLine)
 		}
-		logLine = fmt.Sprintf("[%s] %s", ui.TimestampColorizer(event.Timestamp().Format(format)), logLine)
 	}
-	if options.ShowStreamNames {
-		logLine = fmt.Sprintf("[%s] %s", ui.StreamNameColorizer(streamID), logLine)
+}
+
+// timeFormat returns the format of event timestamp selected by options, or empty string if timestamps are not displayed
+func timeFormat() string {
+	switch {
+	case options.TimeFormat != "":
+		return options.TimeFormat
+	case options.ShowEventTime:
+		return "15:04:05.000"
+	case options.ShowEventTimestamp:
+		return time.RFC3339
 	}
-	return &logLine
+	return ""
 }
 
-func collectAndDisplay(wg *sync.WaitGroup, context *logCollectionContext) {
-	for event := range context.Events {
-		logLine := createLogLine(context, event)
-		if logLine != nil {
-			fmt.Println(*logLine)
+// createHighlights creates a theme from --highlight options
+func createHighlights() *ui.Theme {
+	var def ui.ThemeDefinition
+	for _, spec := range options.Highlight {
+		rule, err := ui.ParseHighlightRule(spec)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(-1)
+		}
+		def.Rules = append(def.Rules, rule)
+	}
+	highlights, err := ui.CompileTheme(def)
+	if err != nil {
+		fmt.Printf("Invalid --highlight option: %v\n", err)
+		os.Exit(-1)
+	}
+	return highlights
+}
+
+// createLevelStyles creates level styles from defaults and --level-style options
+func createLevelStyles() ui.LevelStyles {
+	styles := ui.DefaultLevelStyles()
+	for _, spec := range options.LevelStyle {
+		level, style, err := ui.ParseLevelStyle(spec)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(-1)
 		}
+		styles[level] = style
 	}
-	wg.Done()
+	return styles
 }
 
-func logTailStream(client *cloudwatchlogs.Client, logGroups []string) {
+// createLevelFilter creates level filter from --min-level, --levels and --unknown-level options,
+// returns nil if events should not be filtered by level
+func createLevelFilter() *cwlogs.LevelFilter {
+	if options.MinLevel == "" && options.Levels == "" {
+		return nil
+	}
+	if options.MinLevel != "" && options.Levels != "" {
+		fmt.Println("Only one of --min-level, --levels options allowed")
+		os.Exit(-1)
+	}
+	filter := &cwlogs.LevelFilter{}
+	switch options.UnknownLevel {
+	case "keep":
+		filter.KeepUnknown = true
+	case "drop":
+	default:
+		fmt.Printf("Invalid --unknown-level '%s', expected keep or drop\n", options.UnknownLevel)
+		os.Exit(-1)
+	}
+	var err error
+	if options.MinLevel != "" {
+		filter.MinLevel, err = cwlogs.ParseLevel(options.MinLevel)
+	} else {
+		filter.Levels, err = cwlogs.ParseLevelList(options.Levels)
+	}
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(-1)
+	}
+	return filter
+}
+
+// createTextFilter creates text filter from --filter, --exclude, --match, --ignore-case and --word options,
+// returns nil if events should not be filtered by text
+func createTextFilter() *cwlogs.TextFilter {
+	if len(options.FilterPattern) == 0 && len(options.Exclude) == 0 {
+		return nil
+	}
+	var matchAll bool
+	switch options.Match {
+	case "all":
+		matchAll = true
+	case "any":
+	default:
+		fmt.Printf("Invalid --match '%s', expected all or any\n", options.Match)
+		os.Exit(-1)
+	}
+	filter, err := cwlogs.NewTextFilter(options.FilterPattern, options.Exclude, matchAll, options.IgnoreCase, options.WholeWord)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(-1)
+	}
+	return filter
+}
+
+// createContextLines creates context tracker from -A, -B and -C options, returns nil if context lines
+// are not displayed
+func createContextLines() *ui.ContextLines {
+	before, after := options.Context, options.Context
+	// like in grep, explicit -A and -B override -C, even if they are 0
+	if options.BeforeContext != nil {
+		before = *options.BeforeContext
+	}
+	if options.AfterContext != nil {
+		after = *options.AfterContext
+	}
+	if before < 0 || after < 0 {
+		fmt.Println("Number of context lines can't be negative")
+		os.Exit(-1)
+	}
+	if before == 0 && after == 0 {
+		return nil
+	}
+	return ui.NewContextLines(before, after)
+}
+
+// joinMultiline starts joining events continuing previous events of the same stream, returns the channel
+// with joined events
+func joinMultiline(events chan cwlogs.CWLEvent) chan cwlogs.CWLEvent {
+	pattern, err := regexp.Compile(options.Continuation)
+	if err != nil {
+		fmt.Printf("Invalid --continuation-pattern: %v\n", err)
+		os.Exit(-1)
+	}
+	window, err := time.ParseDuration(options.JoinWindow)
+	if err != nil || window <= 0 {
+		fmt.Printf("Invalid --join-window '%s', expected positive duration, e.g. 500ms\n", options.JoinWindow)
+		os.Exit(-1)
+	}
+	joined := make(chan cwlogs.CWLEvent, 100)
+	go cwlogs.NewMultilineJoiner(pattern, window).Join(events, joined)
+	return joined
+}
+
+// parseDurationOption parses the value of the option, empty value disables the feature and is returned as zero
+func parseDurationOption(name string, value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		fmt.Printf("Invalid %s '%s', expected positive duration, e.g. 30s\n", name, value)
+		os.Exit(-1)
+	}
+	return duration
+}
+
+func collapseRepeats(events chan cwlogs.CWLEvent) chan cwlogs.CWLEvent {
+	if options.Collapse != cwlogs.CollapseStream && options.Collapse != cwlogs.CollapseGlobal {
+		fmt.Printf("Invalid --collapse '%s', expected %s or %s\n", options.Collapse, cwlogs.CollapseStream, cwlogs.CollapseGlobal)
+		os.Exit(-1)
+	}
+	collapsed := make(chan cwlogs.CWLEvent, 100)
+	collapser := cwlogs.NewCollapser(options.Collapse == cwlogs.CollapseGlobal, cwlogs.DefaultCollapseFlush)
+	go collapser.Collapse(events, collapsed)
+	return collapsed
+}
+
+func logTailStream(client *cloudwatchlogs.Client, logGroups []string, streamNames []string, start time.Time, end *time.Time, location *time.Location) {
 	logstream := make(chan cwlogs.CWLEvent, 100)
-	start := time.Now()
 
-	cwlogs.Log(client, logstream, logGroups, &start, nil)
+	cwlogs.Log(client, logstream, logGroups, streamNames, &start, end)
 
 	logCollectorContext := logCollectionContext{
 		LogGroup:  logGroups[0],
 		StartTime: start,
-		EndTime:   nil,
+		EndTime:   end,
 		Events:    logstream,
+		Location:  location,
+	}
+	theme, err := ui.LoadTheme(options.Theme)
+	if err != nil {
+		fmt.Printf("Failed to load theme: %v\n", err)
+		os.Exit(-1)
+	}
+	logCollectorContext.Theme = theme
+	logCollectorContext.Formats, err = ui.NewFormatDetector(options.Format, ui.DefaultFormatSamples)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(-1)
+	}
+	logCollectorContext.Highlights = createHighlights()
+	logCollectorContext.LevelStyles = createLevelStyles()
+	logCollectorContext.LevelFilter = createLevelFilter()
+	if options.LevelField != "" {
+		logCollectorContext.LevelField = ui.ParseJSONPath(options.LevelField)
+	}
+	if options.TimeField != "" {
+		logCollectorContext.TimeField = ui.ParseJSONPath(options.TimeField)
+	}
+	if options.Select != "" {
+		logCollectorContext.Selection, err = query.ParseSelection(options.Select)
+		if err != nil {
+			fmt.Printf("Invalid --select expression: %v\n", err)
+			os.Exit(-1)
+		}
+	}
+	if options.Where != "" {
+		logCollectorContext.Where, err = query.ParseFilter(options.Where)
+		if err != nil {
+			fmt.Printf("Invalid --where expression: %v\n", err)
+			os.Exit(-1)
+		}
+	}
+	if options.Extract != "" {
+		logCollectorContext.ExtractPattern, err = regexp.Compile(options.Extract)
+		if err != nil {
+			fmt.Printf("Invalid --extract pattern: %v\n", err)
+			os.Exit(-1)
+		}
+	}
+	if options.JSON {
+		logCollectorContext.JSONRenderer = ui.NewJSONRenderer(strings.Split(options.Fields, ","), options.Pretty)
+	}
+	if format := timeFormat(); format != "" {
+		logCollectorContext.TimestampFormatter = ui.NewTimestampFormatter(format, location)
 	}
 	if options.ColorPattern != "" {
 		logCollectorContext.HighlightPattern = regexp.MustCompile(options.ColorPattern)
@@ -105,47 +535,207 @@ func logTailStream(client *cloudwatchlogs.Client, logGroups []string) {
 	if options.LevelPattern != "" {
 		logCollectorContext.LevelDetectPattern = regexp.MustCompile(options.LevelPattern)
 	}
-	if options.FilterPattern != "" {
-		if options.FilterPattern[0] == '!' {
-			logCollectorContext.FilterPattern = regexp.MustCompile(options.FilterPattern[1:])
-			logCollectorContext.InvertFilter = true
-		} else {
-			logCollectorContext.FilterPattern = regexp.MustCompile(options.FilterPattern)
-		}
+	logCollectorContext.TextFilter = createTextFilter()
+	logCollectorContext.ContextLines = createContextLines()
+	if options.FoldTraces {
+		logCollectorContext.Traces = cwlogs.NewTraceFolder(options.TraceFrames)
+	}
+	if options.Multiline {
+		logCollectorContext.Events = joinMultiline(logCollectorContext.Events)
+	}
+	if options.Collapse != "" {
+		logCollectorContext.Events = collapseRepeats(logCollectorContext.Events)
+	}
 
+	logCollectorContext.Gap = parseDurationOption("--gap", options.Gap)
+	if end == nil {
+		logCollectorContext.Heartbeat = parseDurationOption("--heartbeat", options.Heartbeat)
+	}
+	if options.Summary {
+		logCollectorContext.Summary = newSummary(time.Now())
+		reportOnSignal(logCollectorContext.Summary)
+	}
+	if options.Summary || options.Patterns || options.Status {
+		logCollectorContext.Interrupted = make(chan os.Signal, 1)
+		signal.Notify(logCollectorContext.Interrupted, os.Interrupt, syscall.SIGTERM)
 	}
 
 	var wg sync.WaitGroup
 
 	wg.Add(1)
-	go collectAndDisplay(&wg, &logCollectorContext)
+	if options.Patterns {
+		go displayPatterns(&wg, &logCollectorContext)
+	} else {
+		go collectAndDisplay(&wg, &logCollectorContext)
+	}
 
 	wg.Wait()
+	if options.Summary {
+		fmt.Fprint(os.Stderr, logCollectorContext.Summary.report(time.Now(), cwlogs.GetAPIStats()))
+	}
 }
 
 type positional struct {
 	LogStream string
 }
 
+type awsOptions struct {
+	AwsProfile  string `arg:"-p,--profile" help:"AWS Profile name"`
+	AwsRegion   string `arg:"--region" help:"AWS Region, overrides region from the profile"`
+	AwsDuration string `arg:"--duration" help:"AWS Session duration" default:"1h"`
+}
+
+// createClient creates Cloudwatch Logs client using the profile and session duration
+func (o awsOptions) createClient() *cloudwatchlogs.Client {
+	duration, err := time.ParseDuration(o.AwsDuration)
+	if err != nil {
+		fmt.Printf("Failed to parse duration: %v", err)
+	}
+
+	return awsi.CreateCloudwatchLogsClient(awsi.ConfigAWS(o.AwsProfile, o.AwsRegion, duration))
+}
+
 var options struct {
+	awsOptions
 	ColorPattern       string   `arg:"-c,--color-pattern" help:"Regex to colorize log lines"`
 	ShowStreamNames    bool     `arg:"-s,--show-stream-names" help:"Show shortened stream names"`
-	AwsProfile         string   `arg:"-p,--profile" help:"AWS Profile name"`
-	AwsDuration        string   `arg:"--duration" help:"AWS Session duration" default:"1h"`
-	LevelHighlight     bool     `arg:"-w,--level-highlight" help:"Enable highlighting for log events of WARN and ERROR levels"`
-	LevelPattern       string   `arg:"-l,--level-pattern" help:"Regex to extract log level from the log event" default:"(?i)\\b(?:(?P<warning>warn|warning)|(?P<error>error))\\b"`
+	LevelHighlight     bool     `arg:"-w,--level-highlight" help:"Enable highlighting of log events based on their log level"`
+	LevelPattern       string   `arg:"-l,--level-pattern" help:"Regex to extract log level from the log event, with named groups trace, debug, info, warning, error and fatal"`
+	LevelStyle         []string `arg:"--level-style,separate" help:"Highlighting of the log level in LEVEL=MODE[:STYLE] format, where MODE is line, fg, token or none. Can be repeated"`
+	LevelField         string   `arg:"--level-field" help:"Name of the field containing log level in JSON messages, nested fields are separated with dots, e.g. log.level"`
+	TimeField          string   `arg:"--time-field" help:"Name of the field containing event time in JSON messages, displayed instead of Cloudwatch event timestamp"`
+	JSON               bool     `arg:"-j,--json" help:"Render JSON messages as values of --fields followed by the rest of the fields as key=value pairs"`
+	Fields             string   `arg:"--fields" help:"Comma-separated fields of JSON messages displayed first, e.g. time,level,msg"`
+	Pretty             bool     `arg:"--pretty" help:"With --json, display each of the remaining fields on its own line"`
+	Where              string   `arg:"--where" help:"Display only events matching the expression over message fields and event metadata, e.g. 'level == \"ERROR\" and (status >= 500 or msg =~ /timeout/i)'"`
+	Extract            string   `arg:"--extract" help:"Regex with named groups, which extract fields from messages for --where and --select expressions"`
+	Select             string   `arg:"--select" help:"Display only values of comma-separated jq-like paths of JSON messages and event metadata, e.g. '@timestamp, .request.path, .status'"`
+	MinLevel           string   `arg:"--min-level" help:"Display only events of this log level or more severe"`
+	Levels             string   `arg:"--levels" help:"Display only events of the listed log levels, e.g. error,fatal"`
+	UnknownLevel       string   `arg:"--unknown-level" help:"Whether events without detectable log level are kept or dropped by --min-level and --levels, keep or drop" default:"keep"`
 	DebugLogs          bool     `arg:"--debug-logs" help:"Enable debug logging to debug.log file"`
-	FilterPattern      string   `arg:"-f,--filter" help:"Display only lines that match provided regular expression"`
+	FilterPattern      []string `arg:"-f,--filter,separate" help:"Display only lines that match provided regular expression, prefix with '!' to discard matching lines instead. Can be repeated"`
+	Exclude            []string `arg:"-x,--exclude,separate" help:"Discard lines that match provided regular expression. Can be repeated"`
+	OneLine            bool     `arg:"--one-line" help:"Display multi-line messages in one line, with newlines escaped as \\n"`
+	Multiline          bool     `arg:"-m,--multiline" help:"Join events continuing the previous event of the same stream, such as separate lines of a stack trace, into one event"`
+	Continuation       string   `arg:"--continuation-pattern" help:"Regex matching events which continue the previous event, used with --multiline"`
+	JoinWindow         string   `arg:"--join-window" help:"Longest time between events joined with --multiline" default:"1s"`
+	FoldTraces         bool     `arg:"--fold-traces" help:"Fold Java, Python, Go and Node stack traces to the exception line and innermost frames, and replace repeated traces with a note"`
+	TraceFrames        int      `arg:"--trace-frames" help:"Number of innermost frames of stack traces displayed with --fold-traces" default:"3"`
+	Collapse           string   `arg:"--collapse" help:"Replace consecutive repeated messages, equal after masking numbers and UUIDs, with 'last message repeated N times', per stream or global"`
+	Patterns           bool     `arg:"--patterns" help:"Group events into templates by masking variable tokens and display a live table of the most frequent templates instead of the events"`
+	Summary            bool     `arg:"--summary" help:"Print statistics of the received events and API usage on exit. The statistics are also printed on SIGUSR1"`
+	Status             bool     `arg:"--status" help:"Display status line with the number of streams, event rate, time since the last event, ingestion lag and API errors at the bottom of the terminal"`
+	Gap                string   `arg:"--gap" help:"Display a separator when time between consecutive displayed events is longer than this, e.g. 30s"`
+	Heartbeat          string   `arg:"--heartbeat" help:"When following the log, display a line after this long without displayed events, e.g. 5m"`
+	AfterContext       *int     `arg:"-A,--after-context" help:"Display this number of lines of the same stream after each matching line, overrides -C even if 0"`
+	BeforeContext      *int     `arg:"-B,--before-context" help:"Display this number of lines of the same stream before each matching line, overrides -C even if 0"`
+	Context            int      `arg:"-C,--context" help:"Display this number of lines of the same stream before and after each matching line"`
+	Match              string   `arg:"--match" help:"Whether lines must match all or any of --filter expressions" default:"any"`
+	IgnoreCase         bool     `arg:"--ignore-case" help:"Make --filter and --exclude expressions case-insensitive"`
+	WholeWord          bool     `arg:"--word" help:"Make --filter and --exclude expressions match only whole words"`
 	ShowEventTime      bool     `arg:"-t,--show-event-time" help:"Displays Cloudwatch event time in ISO8601 format. This displays only the time portion of timestamp"`
 	ShowEventTimestamp bool     `arg:"-i,--show-event-timestamp" help:"Displays Cloudwatch event timestamp in ISO8601 format"`
+	Highlight          []string `arg:"-H,--highlight,separate" help:"Highlight every match of the pattern with the style, in PATTERN=style format. Can be repeated"`
 	NoHighlighting     bool     `arg:"--no-highlighting" help:"Disables color highlighting of parts of the log message"`
-	LogGroups          []string `arg:"positional,required"`
+	Theme              string   `arg:"--theme" help:"Highlighting theme, either built-in dark or light theme, or a path to the theme file" default:"dark"`
+	Format             string   `arg:"--format" help:"Log format used for highlighting: auto, plain, json, logfmt, java, python or access. In auto mode the format of each stream is detected from its first events" default:"auto"`
+	Color              string   `arg:"--color" help:"Use colors: auto, always or never. In auto mode colors are used only when output is a terminal" default:"auto"`
+	StartTime          string   `arg:"--start" help:"Display events starting from this time, e.g. 15m, 2h ago, yesterday 14:00, 2026-10-16T10:00 or epoch milliseconds"`
+	EndTime            string   `arg:"--end" help:"Display events up to this time and exit, accepts the same expressions as --start"`
+	TimeFormat         string   `arg:"--time-format" help:"Displays Cloudwatch event timestamp in this format, either Go time layout or strftime-style format. 'delta' displays time since the previous event, 'age' displays how long ago the event happened"`
+	TimeZone           string   `arg:"--tz" help:"Time zone for time expressions and displayed timestamps: UTC, local or Area/City" default:"local"`
+	LogGroups          []string `arg:"positional" help:"Log groups to tail, if omitted log groups are selected interactively"`
+}
+
+func countTrue(values ...bool) int {
+	count := 0
+	for _, v := range values {
+		if v {
+			count++
+		}
+	}
+	return count
+}
+
+func parseTimeOption(name string, expr string, now time.Time, location *time.Location) time.Time {
+	tm, err := cwlogs.ParseTimeExpression(expr, now, location)
+	if err != nil {
+		fmt.Printf("Invalid %s value: %v\n", name, err)
+		os.Exit(-1)
+	}
+	return tm
+}
+
+// parseArgs parses command line arguments into dest, printing help or usage and exiting when needed
+func parseArgs(program string, args []string, dest interface{}) {
+	p, err := arg.NewParser(arg.Config{Program: program}, dest)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(-1)
+	}
+	err = p.Parse(args)
+	if err == arg.ErrHelp {
+		p.WriteHelp(os.Stdout)
+		os.Exit(0)
+	}
+	if err != nil {
+		p.Fail(err.Error())
+	}
+}
+
+// loadConfig loads the configuration file and returns option values for the preset
+func loadConfig(preset string) map[string]interface{} {
+	cfg, err := config.Load(config.DefaultPath())
+	if err != nil {
+		fmt.Printf("Failed to load configuration: %v\n", err)
+		os.Exit(-1)
+	}
+	values, err := cfg.Preset(preset)
+	if err != nil {
+		fmt.Printf("Failed to load configuration: %v\n", err)
+		os.Exit(-1)
+	}
+	return values
+}
+
+// parseOptions parses command line arguments into options. Values from the configuration file and
+// the preset, if the first argument is @preset-name, are used unless overridden on the command line
+func parseOptions(args []string) {
+	var preset string
+	if len(args) > 0 && strings.HasPrefix(args[0], "@") {
+		preset = args[0][1:]
+		args = args[1:]
+	}
+	options.LevelPattern = cwlogs.DefaultLevelPattern
+	options.Continuation = cwlogs.DefaultContinuationPattern
+	// configuration is loaded after parsing, so that --help works with a broken configuration file,
+	// and configured values override go-arg defaults even if they are zero
+	parseArgs("cwltail", args, &options)
+	values := loadConfig(preset)
+	if err := config.Apply(&options, values, args, true); err != nil {
+		fmt.Printf("Invalid configuration: %v\n", err)
+		os.Exit(-1)
+	}
 }
 
 func main() {
-	arg.MustParse(&options)
-	if options.ShowEventTime && options.ShowEventTimestamp {
-		fmt.Println("Only one of --show-event-time, --show-event-timestamp options allowed")
+	// log groups named "groups" or "streams" are tailed with "cwltail -- groups", because only the first
+	// argument is checked for the subcommand
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "groups":
+			listGroups(os.Args[2:])
+			return
+		case "streams":
+			listStreams(os.Args[2:])
+			return
+		}
+	}
+
+	parseOptions(os.Args[1:])
+	if countTrue(options.ShowEventTime, options.ShowEventTimestamp, options.TimeFormat != "") > 1 {
+		fmt.Println("Only one of --show-event-time, --show-event-timestamp, --time-format options allowed")
 		os.Exit(-1)
 	}
 
@@ -159,12 +749,39 @@ func main() {
 		log.SetLevel(log.WarnLevel)
 	}
 
-	duration, err := time.ParseDuration(options.AwsDuration)
+	colorLevel, err := ui.DetectColorLevel(options.Color, ui.StdoutIsTerminal(), os.Getenv)
 	if err != nil {
-		fmt.Printf("Failed to parse duration: %v", err)
+		fmt.Println(err)
+		os.Exit(-1)
 	}
+	ui.SetColorLevel(colorLevel)
 
-	client := awsi.CreateCloudwatchLogsClient(awsi.ConfigAWS(options.AwsProfile, duration))
+	location, err := cwlogs.ParseLocation(options.TimeZone)
+	if err != nil {
+		fmt.Printf("Invalid time zone: %v\n", err)
+		os.Exit(-1)
+	}
+	now := time.Now()
+	start := now
+	if options.StartTime != "" {
+		start = parseTimeOption("--start", options.StartTime, now, location)
+	}
+	var end *time.Time
+	if options.EndTime != "" {
+		e := parseTimeOption("--end", options.EndTime, now, location)
+		if !e.After(start) {
+			fmt.Println("--end must be after --start")
+			os.Exit(-1)
+		}
+		end = &e
+	}
+
+	client := options.createClient()
+	logGroups := options.LogGroups
+	var streamNames []string
+	if len(logGroups) == 0 {
+		logGroups, streamNames = pickLogGroupsAndStreams(client)
+	}
 
-	logTailStream(client, options.LogGroups)
+	logTailStream(client, logGroups, streamNames, start, end, location)
 }
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/uaraven/cwltail/config"
+)
+
+// parseWithConfig parses the command line with the configuration file containing text, and restores
+// the options afterwards
+func parseWithConfig(t *testing.T, text string, args ...string) {
+	dir, err := ioutil.TempDir("", "cwltail")
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	path := filepath.Join(dir, "config.yaml")
+	if err = ioutil.WriteFile(path, []byte(text), 0600); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	saved := options
+	previous, wasSet := os.LookupEnv(config.PathEnvVariable)
+	os.Setenv(config.PathEnvVariable, path)
+	t.Cleanup(func() {
+		options = saved
+		if wasSet {
+			os.Setenv(config.PathEnvVariable, previous)
+		} else {
+			os.Unsetenv(config.PathEnvVariable)
+		}
+		os.RemoveAll(dir)
+	})
+	parseOptions(args)
+}
+
+func TestContextOptionsFromConfig(t *testing.T) {
+	parseWithConfig(t, "context: 3\nafter-context: 0\nbefore-context: 2\n", "/group")
+	if options.AfterContext == nil || *options.AfterContext != 0 || options.BeforeContext == nil || *options.BeforeContext != 2 {
+		t.Errorf("Unexpected context options %v %v", options.AfterContext, options.BeforeContext)
+	}
+	if options.Context != 3 {
+		t.Errorf("Expected context 3, got %d", options.Context)
+	}
+}
+
+func TestContextOptionsOverrideConfig(t *testing.T) {
+	parseWithConfig(t, "after-context: 2\n", "-A", "5", "/group")
+	if options.AfterContext == nil || *options.AfterContext != 5 || options.BeforeContext != nil {
+		t.Errorf("Unexpected context options %v %v", options.AfterContext, options.BeforeContext)
+	}
+}
+
+func TestZeroConfigValueOverridesDefault(t *testing.T) {
+	parseWithConfig(t, "trace-frames: 0\ntheme: light\n", "--theme", "dark", "/group")
+	if options.TraceFrames != 0 || options.Theme != "dark" {
+		t.Errorf("Expected trace frames 0 and dark theme, got %d %s", options.TraceFrames, options.Theme)
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/uaraven/cwltail/cwlogs"
+	"github.com/uaraven/cwltail/ui"
+)
+
+const (
+	// patternRefresh is how often the live pattern table is redrawn
+	patternRefresh = time.Second
+	// patternReportSize is the number of patterns printed when the pattern view exits
+	patternReportSize = 50
+)
+
+// renderPatterns renders up to count most frequent patterns fitting into the terminal width
+func renderPatterns(context *logCollectionContext, counter *cwlogs.PatternCounter, count int, width int) string {
+	now := time.Now()
+	var rows []ui.PatternRow
+	for _, stats := range counter.Top(count, now) {
+		rows = append(rows, ui.PatternRow{
+			Template: stats.Template,
+			Count:    stats.Count,
+			Rate:     stats.Rate,
+			Sample:   stats.Sample,
+		})
+	}
+	title := fmt.Sprintf("%s: %d events, %d patterns, %s", context.LogGroup, counter.Total(), counter.Len(),
+		now.In(context.Location).Format("15:04:05"))
+	var sb strings.Builder
+	for i, line := range ui.RenderPatterns(title, rows, width) {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(line.Render())
+	}
+	return sb.String()
+}
+
+// displayPatterns groups displayed events by their templates instead of printing them. On terminals the table
+// of the most frequent templates is redrawn every second, the final table is printed when the events end
+// or cwltail is interrupted
+func displayPatterns(wg *sync.WaitGroup, context *logCollectionContext) {
+	defer wg.Done()
+	counter := cwlogs.NewPatternCounter()
+	live := ui.StdoutIsTerminal()
+	ticker := time.NewTicker(patternRefresh)
+	defer ticker.Stop()
+	if live {
+		// use alternate screen buffer, so only the final table is left behind
+		fmt.Print("\033[?1049h")
+	}
+loop:
+	for {
+		select {
+		case event, ok := <-context.Events:
+			if !ok {
+				break loop
+			}
+			if _, isNote := event.(*cwlogs.RepeatNote); isNote {
+				context.Summary.add(event, false)
+				continue
+			}
+			_, matched := createLogLine(context, event)
+			if matched {
+				counter.Add(event.Message(), time.Now())
+			}
+			context.Summary.add(event, matched)
+		case <-ticker.C:
+			if live {
+				width, height := ui.TerminalSize()
+				// two header lines, and each pattern takes two lines
+				fmt.Print("\033[H\033[2J" + renderPatterns(context, counter, (height-3)/2, width))
+			}
+		case <-context.Interrupted:
+			break loop
+		}
+	}
+	if live {
+		fmt.Print("\033[?1049l")
+	}
+	width, _ := ui.TerminalSize()
+	fmt.Println(renderPatterns(context, counter, patternReportSize, width))
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+
+	"github.com/uaraven/cwltail/cwlogs"
+	"github.com/uaraven/cwltail/ui"
+)
+
+const allStreams = "(all streams)"
+
+func exitOnPickError(err error) {
+	if err == ui.ErrPickerCancelled {
+		os.Exit(0)
+	}
+	fmt.Printf("Failed to select log groups: %v\n", err)
+	os.Exit(-1)
+}
+
+// pickLogGroupsAndStreams lets user interactively select log groups to tail. If only one log group is selected
+// user can also select log streams in that group
+func pickLogGroupsAndStreams(client *cloudwatchlogs.Client) ([]string, []string) {
+	groups, err := cwlogs.ListGroupNames(client, "")
+	if err != nil {
+		exitOnPickError(err)
+	}
+	if len(groups) == 0 {
+		fmt.Println("No log groups found")
+		os.Exit(-1)
+	}
+	selectedGroups, err := ui.Pick("Log groups", groups, true)
+	if err != nil {
+		exitOnPickError(err)
+	}
+	if len(selectedGroups) > 1 {
+		return selectedGroups, nil
+	}
+
+	streams, err := cwlogs.ListStreams(client, selectedGroups[0])
+	if err != nil {
+		exitOnPickError(err)
+	}
+	streamNames := make([]string, 0, len(streams)+1)
+	streamNames = append(streamNames, allStreams)
+	for _, s := range streams {
+		streamNames = append(streamNames, s.Name)
+	}
+	selectedStreams, err := ui.Pick("Log streams in "+selectedGroups[0], streamNames, true)
+	if err != nil {
+		exitOnPickError(err)
+	}
+	for _, s := range selectedStreams {
+		if s == allStreams {
+			return selectedGroups, nil
+		}
+	}
+	return selectedGroups, selectedStreams
+}
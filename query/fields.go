@@ -0,0 +1,65 @@
+package query
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var logfmtPair = regexp.MustCompile(`^\s*([\w.\-/]+)=("(?:[^"\\]|\\.)*"|\S*)`)
+
+// ParseLogfmt parses the message if it consists of logfmt key=value pairs, e.g. level=info msg="started"
+func ParseLogfmt(message string) (*Object, bool) {
+	fields := NewObject()
+	rest := message
+	for strings.TrimSpace(rest) != "" {
+		m := logfmtPair.FindStringSubmatchIndex(rest)
+		if m == nil {
+			return nil, false
+		}
+		value := rest[m[4]:m[5]]
+		if strings.HasPrefix(value, `"`) {
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return nil, false
+			}
+			value = unquoted
+		}
+		fields.Set(rest[m[2]:m[3]], value)
+		rest = rest[m[1]:]
+		if rest != "" && rest[0] != ' ' && rest[0] != '\t' {
+			return nil, false
+		}
+	}
+	if len(fields.Keys) < 2 {
+		return nil, false
+	}
+	return fields, true
+}
+
+// ParseFields parses the message if it is a JSON object or logfmt key=value pairs and returns its fields
+// and format, FormatJSON or FormatLogfmt. Unstructured messages have nil fields and empty format
+func ParseFields(message string) (*Object, string) {
+	if fields, ok := ParseJSON(message); ok {
+		return fields, FormatJSON
+	}
+	if fields, ok := ParseLogfmt(message); ok {
+		return fields, FormatLogfmt
+	}
+	return nil, ""
+}
+
+// ExtractFields returns values of the named groups of the pattern which matched the message
+func ExtractFields(pattern *regexp.Regexp, message string) *Object {
+	m := pattern.FindStringSubmatchIndex(message)
+	if m == nil {
+		return nil
+	}
+	fields := NewObject()
+	for i, name := range pattern.SubexpNames() {
+		if i > 0 && name != "" && m[2*i] >= 0 {
+			fields.Set(name, message[m[2*i]:m[2*i+1]])
+		}
+	}
+	return fields
+}
@@ -0,0 +1,376 @@
+package query
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// boolExpr is a condition in the filter expression
+type boolExpr interface {
+	match(r *Record) bool
+}
+
+type andExpr struct {
+	operands []boolExpr
+}
+
+func (e *andExpr) match(r *Record) bool {
+	for _, operand := range e.operands {
+		if !operand.match(r) {
+			return false
+		}
+	}
+	return true
+}
+
+type orExpr struct {
+	operands []boolExpr
+}
+
+func (e *orExpr) match(r *Record) bool {
+	for _, operand := range e.operands {
+		if operand.match(r) {
+			return true
+		}
+	}
+	return false
+}
+
+type notExpr struct {
+	operand boolExpr
+}
+
+func (e *notExpr) match(r *Record) bool {
+	return !e.operand.match(r)
+}
+
+// truthExpr is a value used as a condition. As in jq, only missing values, null and false are false
+type truthExpr struct {
+	value valueExpr
+}
+
+func (e *truthExpr) match(r *Record) bool {
+	value, ok := e.value.eval(r)
+	if !ok || value == nil {
+		return false
+	}
+	b, isBool := value.(bool)
+	return !isBool || b
+}
+
+type compareExpr struct {
+	op    string
+	left  valueExpr
+	right valueExpr
+}
+
+func (e *compareExpr) match(r *Record) bool {
+	left, _ := e.left.eval(r)
+	right, _ := e.right.eval(r)
+	switch e.op {
+	case "==":
+		return equal(left, right)
+	case "!=":
+		return !equal(left, right)
+	}
+	c, ok := compare(left, right)
+	if !ok {
+		return false
+	}
+	switch e.op {
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	default:
+		return c >= 0
+	}
+}
+
+type matchExpr struct {
+	negate bool
+	value  valueExpr
+	re     *regexp.Regexp
+}
+
+func (e *matchExpr) match(r *Record) bool {
+	value, _ := e.value.eval(r)
+	s, ok := toString(value)
+	return ok && e.re.MatchString(s) != e.negate
+}
+
+func toNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func toString(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case nil:
+		return "", false
+	case string:
+		return v, true
+	case json.Number:
+		return v.String(), true
+	case bool:
+		return strconv.FormatBool(v), true
+	case time.Time:
+		return v.Format(time.RFC3339Nano), true
+	}
+	data, err := json.Marshal(value)
+	return string(data), err == nil
+}
+
+func toTime(value interface{}) (time.Time, bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, true
+	case string:
+		tm, err := time.Parse(time.RFC3339Nano, v)
+		return tm, err == nil
+	case json.Number:
+		ms, err := v.Int64()
+		return time.Unix(0, ms*int64(time.Millisecond)), err == nil
+	}
+	return time.Time{}, false
+}
+
+func equal(left interface{}, right interface{}) bool {
+	if left == nil || right == nil {
+		return left == nil && right == nil
+	}
+	if c, ok := compare(left, right); ok {
+		return c == 0
+	}
+	l, lok := toString(left)
+	r, rok := toString(right)
+	return lok && rok && l == r
+}
+
+// compare compares values as times, if one of them is time, or as numbers, if both are numeric,
+// or as strings. Missing and null values can't be compared
+func compare(left interface{}, right interface{}) (int, bool) {
+	if left == nil || right == nil {
+		return 0, false
+	}
+	_, leftTime := left.(time.Time)
+	_, rightTime := right.(time.Time)
+	if leftTime || rightTime {
+		l, lok := toTime(left)
+		r, rok := toTime(right)
+		if !lok || !rok {
+			return 0, false
+		}
+		switch {
+		case l.Before(r):
+			return -1, true
+		case l.After(r):
+			return 1, true
+		}
+		return 0, true
+	}
+	if l, lok := toNumber(left); lok {
+		if r, rok := toNumber(right); rok {
+			switch {
+			case l < r:
+				return -1, true
+			case l > r:
+				return 1, true
+			}
+			return 0, true
+		}
+	}
+	l, lok := toString(left)
+	r, rok := toString(right)
+	if !lok || !rok {
+		return 0, false
+	}
+	return strings.Compare(l, r), true
+}
+
+// Filter is a parsed filter expression
+type Filter struct {
+	root boolExpr
+}
+
+// ParseFilter parses filter expression, e.g.
+//
+//	level == "ERROR" and (status >= 500 or msg =~ /timeout/i) and not path =~ "^/health"
+//
+// Operands are field names of the structured message, which can be nested (request.path) and use the same
+// syntax as --select paths, event metadata (@timestamp, @stream, @group, @message, @level) and literals.
+// Comparison operators are ==, !=, <, <=, > and >=, numeric values are compared as numbers.
+// =~ and !~ match the value with a regular expression, either /regex/flags or a string.
+// Conditions are combined with and, or, not and parentheses. An operand without comparison is true
+// if the value is present and is neither null nor false
+func ParseFilter(expr string) (*Filter, error) {
+	p := &parser{input: expr, bareNames: true}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek() != 0 {
+		return nil, p.errorf(p.pos, "unexpected '%c', expected 'and', 'or' or end of expression", p.input[p.pos])
+	}
+	return &Filter{root: root}, nil
+}
+
+// Match returns true if the record satisfies the filter
+func (f *Filter) Match(r *Record) bool {
+	return f.root.match(r)
+}
+
+func (p *parser) parseOr() (boolExpr, error) {
+	operand, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	operands := []boolExpr{operand}
+	for p.consumeKeyword("or") {
+		if operand, err = p.parseAnd(); err != nil {
+			return nil, err
+		}
+		operands = append(operands, operand)
+	}
+	if len(operands) == 1 {
+		return operands[0], nil
+	}
+	return &orExpr{operands: operands}, nil
+}
+
+func (p *parser) parseAnd() (boolExpr, error) {
+	operand, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	operands := []boolExpr{operand}
+	for p.consumeKeyword("and") {
+		if operand, err = p.parseUnary(); err != nil {
+			return nil, err
+		}
+		operands = append(operands, operand)
+	}
+	if len(operands) == 1 {
+		return operands[0], nil
+	}
+	return &andExpr{operands: operands}, nil
+}
+
+func (p *parser) parseUnary() (boolExpr, error) {
+	if p.consumeKeyword("not") {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notExpr{operand: operand}, nil
+	}
+	if p.consume("(") {
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err = p.expect(")"); err != nil {
+			return nil, err
+		}
+		return expr, nil
+	}
+	return p.parseComparison()
+}
+
+var comparisonOperators = []string{"==", "!=", "=~", "!~", "<=", ">=", "<", ">"}
+
+func (p *parser) parseComparison() (boolExpr, error) {
+	if c := p.peek(); c == 0 || c == ')' {
+		return nil, p.errorf(p.pos, "expected condition")
+	}
+	left, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpaces()
+	opPos := p.pos
+	op := ""
+	for _, candidate := range comparisonOperators {
+		if p.consume(candidate) {
+			op = candidate
+			break
+		}
+	}
+	switch op {
+	case "":
+		if p.peek() == '=' {
+			return nil, p.errorf(opPos, "unexpected '=', use '==' to compare values")
+		}
+		return &truthExpr{value: left}, nil
+	case "=~", "!~":
+		re, err := p.parseRegex()
+		if err != nil {
+			return nil, err
+		}
+		return &matchExpr{negate: op == "!~", value: left, re: re}, nil
+	}
+	right, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	return &compareExpr{op: op, left: left, right: right}, nil
+}
+
+// parseRegex parses /regex/flags or a string containing regular expression. Supported flags are
+// i (case-insensitive), m (multi-line) and s (dot matches new line)
+func (p *parser) parseRegex() (*regexp.Regexp, error) {
+	c := p.peek()
+	start := p.pos
+	var pattern string
+	switch c {
+	case '"':
+		s, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		pattern = s
+	case '/':
+		p.pos++
+		var sb strings.Builder
+		for p.pos < len(p.input) && p.input[p.pos] != '/' {
+			if p.input[p.pos] == '\\' && p.pos+1 < len(p.input) && p.input[p.pos+1] == '/' {
+				p.pos++
+			}
+			sb.WriteByte(p.input[p.pos])
+			p.pos++
+		}
+		if p.pos >= len(p.input) {
+			return nil, p.errorf(start, "unterminated regular expression")
+		}
+		p.pos++
+		flags := p.parseIdent()
+		for i, flag := range flags {
+			if !strings.ContainsRune("ims", flag) {
+				return nil, p.errorf(p.pos-len(flags)+i, "unknown regular expression flag '%c'", flag)
+			}
+		}
+		pattern = sb.String()
+		if flags != "" {
+			pattern = "(?" + flags + ")" + pattern
+		}
+	default:
+		return nil, p.errorf(start, "expected regular expression, either /regex/ or a string")
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, p.errorf(start, "invalid regular expression: %v", err)
+	}
+	return re, nil
+}
@@ -0,0 +1,126 @@
+package query
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFilter(t *testing.T) {
+	fields, _ := ParseJSON(`{"level":"ERROR","status":503,"msg":"upstream Timeout","path":"/api/orders","request":{"method":"POST"},"user":null,"cached":false,"tags":["a","b"]}`)
+	record := &Record{
+		Fields:    fields,
+		Timestamp: time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC),
+		Group:     "/ecs/api",
+		Stream:    "api/web/123",
+		Level:     "error",
+	}
+	tests := []struct {
+		expr     string
+		expected bool
+	}{
+		{`level == "ERROR" and (status >= 500 or msg =~ /timeout/i) and not path =~ "^/health"`, true},
+		{`level == "ERROR" and status < 500`, false},
+		{`status == 503 and status != 500 and status > 500.5 and status <= 503`, true},
+		{`status == "503"`, true},
+		{`msg =~ /timeout/`, false},
+		{`msg !~ /timeout/`, true},
+		{`request.method == "POST" and .request.method == "POST"`, true},
+		{`tags[1] == "b"`, true},
+		{`missing == null and user == null and missing != 1`, true},
+		{`missing > 0 or missing =~ /.*/`, false},
+		{`missing !~ /x/`, false},
+		{`request and not user and not cached and not missing`, true},
+		{`@level == "error" and @stream =~ "^api/" and @group == "/ecs/api"`, true},
+		{`@timestamp >= "2026-10-19T09:00:00Z" and @timestamp < "2026-10-19T11:00:00Z"`, true},
+		{`(level == "INFO" or level == "WARN") or not (status < 500)`, true},
+		{`level=="ERROR"and status>=500`, true},
+		{`user // "anonymous" == "anonymous"`, true},
+	}
+	for _, test := range tests {
+		filter, err := ParseFilter(test.expr)
+		if err != nil {
+			t.Errorf("'%s': unexpected error %v", test.expr, err)
+			continue
+		}
+		if actual := filter.Match(record); actual != test.expected {
+			t.Errorf("'%s': expected %v, got %v", test.expr, test.expected, actual)
+		}
+	}
+}
+
+func TestFilterLogfmtAndExtractedFields(t *testing.T) {
+	fields, format := ParseFields(`level=warn msg="slow query" duration=1500`)
+	if format != FormatLogfmt {
+		t.Fatalf("Expected logfmt fields, got %q", format)
+	}
+	filter, err := ParseFilter(`level == "warn" and duration > 1000 and msg =~ "slow"`)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if !filter.Match(&Record{Fields: fields}) {
+		t.Errorf("Expected logfmt message to match")
+	}
+
+	pattern := regexp.MustCompile(`took (?P<duration>\d+)ms`)
+	extracted := ExtractFields(pattern, "query took 1200ms")
+	logfmt, _ := ParseLogfmt(`level=warn msg=slow`)
+	if !filter.Match(&Record{Fields: logfmt.Merge(extracted)}) {
+		t.Errorf("Expected extracted fields to match")
+	}
+	if ExtractFields(pattern, "no match") != nil {
+		t.Errorf("Expected no fields")
+	}
+}
+
+func TestParseLogfmt(t *testing.T) {
+	tests := map[string]bool{
+		`level=info msg="started server" port=8080`: true,
+		`a=1 b=`:                  true,
+		`retries=3`:               false,
+		`Starting server port=80`: false,
+		`a=1 b="unterminated`:     false,
+	}
+	for message, expected := range tests {
+		if _, ok := ParseLogfmt(message); ok != expected {
+			t.Errorf("'%s': expected %v", message, expected)
+		}
+	}
+	fields, _ := ParseLogfmt(`msg="say \"hi\"" n=1`)
+	if fields.Values["msg"] != `say "hi"` || fields.Values["n"] != "1" || !reflect.DeepEqual(fields.Keys, []string{"msg", "n"}) {
+		t.Errorf("Unexpected fields %v", fields)
+	}
+}
+
+func TestFilterErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		pos  int
+		msg  string
+	}{
+		{`level = "ERROR"`, 6, "unexpected '=', use '=='"},
+		{`level == `, 9, "expected value"},
+		{`(status > 500`, 13, "expected ')'"},
+		{`status > 500 level == "x"`, 13, "unexpected 'l'"},
+		{`msg =~ timeout`, 7, "expected regular expression"},
+		{`msg =~ /time(out/`, 7, "invalid regular expression"},
+		{`msg =~ /timeout`, 7, "unterminated regular expression"},
+		{`msg =~ /timeout/x`, 16, "unknown regular expression flag 'x'"},
+		{`level == "ERROR" and`, 20, "expected condition"},
+		{``, 0, "expected condition"},
+		{`@host == "a"`, 0, "unknown metadata '@host'"},
+	}
+	for _, test := range tests {
+		_, err := ParseFilter(test.expr)
+		syntaxErr, ok := err.(*SyntaxError)
+		if !ok {
+			t.Errorf("'%s': expected syntax error, got %v", test.expr, err)
+			continue
+		}
+		if syntaxErr.Pos != test.pos || !strings.HasPrefix(syntaxErr.Msg, test.msg) {
+			t.Errorf("'%s': expected '%s' at %d, got '%s' at %d", test.expr, test.msg, test.pos, syntaxErr.Msg, syntaxErr.Pos)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package query
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Formats of the structured messages
+const (
+	FormatJSON   = "json"
+	FormatLogfmt = "logfmt"
+)
+
+// Object is a parsed JSON object or logfmt message, which keeps the order of its fields.
+// Values are strings, json.Number, booleans, nil, []interface{} or *Object
+type Object struct {
+	Keys   []string
+	Values map[string]interface{}
+}
+
+// NewObject creates an empty object
+func NewObject() *Object {
+	return &Object{Values: make(map[string]interface{})}
+}
+
+// Set sets the value of the field, new fields are added after the existing ones
+func (o *Object) Set(key string, value interface{}) {
+	if _, exists := o.Values[key]; !exists {
+		o.Keys = append(o.Keys, key)
+	}
+	o.Values[key] = value
+}
+
+// Lookup returns the value at the path of field names, e.g. ["log", "level"] for {"log": {"level": "WARN"}}
+func (o *Object) Lookup(path []string) (interface{}, bool) {
+	var value interface{} = o
+	for _, field := range path {
+		obj, ok := value.(*Object)
+		if !ok {
+			return nil, false
+		}
+		if value, ok = obj.Values[field]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// Merge returns a new object with the fields of this object followed by the fields of the other object
+// which are missing in this one. Neither of the objects is modified
+func (o *Object) Merge(other *Object) *Object {
+	merged := NewObject()
+	for _, key := range o.Keys {
+		merged.Set(key, o.Values[key])
+	}
+	for _, key := range other.Keys {
+		if _, exists := merged.Values[key]; !exists {
+			merged.Set(key, other.Values[key])
+		}
+	}
+	return merged
+}
+
+// ParseJSON parses the message if it is a JSON object. Numbers are kept as json.Number
+func ParseJSON(message string) (*Object, bool) {
+	trimmed := strings.TrimSpace(message)
+	if !strings.HasPrefix(trimmed, "{") || !json.Valid([]byte(trimmed)) {
+		return nil, false
+	}
+	decoder := json.NewDecoder(strings.NewReader(trimmed))
+	decoder.UseNumber()
+	value, err := decodeJSONValue(decoder)
+	if err != nil {
+		return nil, false
+	}
+	obj, ok := value.(*Object)
+	return obj, ok
+}
+
+func decodeJSONValue(decoder *json.Decoder) (interface{}, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch token {
+	case json.Delim('{'):
+		obj := NewObject()
+		for decoder.More() {
+			keyToken, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			key, _ := keyToken.(string)
+			value, err := decodeJSONValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			obj.Set(key, value)
+		}
+		_, err = decoder.Token()
+		return obj, err
+	case json.Delim('['):
+		array := []interface{}{}
+		for decoder.More() {
+			value, err := decodeJSONValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, value)
+		}
+		_, err = decoder.Token()
+		return array, err
+	}
+	return token, nil
+}
@@ -0,0 +1,63 @@
+package query
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseJSON(t *testing.T) {
+	obj, ok := ParseJSON(` {"b":1,"a":{"y":"v","x":[true,null]},"b":2} `)
+	if !ok {
+		t.Fatalf("Expected object")
+	}
+	if !reflect.DeepEqual(obj.Keys, []string{"b", "a"}) {
+		t.Errorf("Unexpected keys %v", obj.Keys)
+	}
+	value, ok := obj.Lookup([]string{"a", "y"})
+	if !ok || value != "v" {
+		t.Errorf("Expected v, got %v %v", value, ok)
+	}
+	if _, ok = obj.Lookup([]string{"b", "c"}); ok {
+		t.Errorf("Expected missing value")
+	}
+	for _, message := range []string{`[1,2]`, `{"a":1} x`, `{"a":1}{"b":2}`, `{"a":`, `plain text`} {
+		if _, ok := ParseJSON(message); ok {
+			t.Errorf("%q: expected no object", message)
+		}
+	}
+}
+
+func TestParseFields(t *testing.T) {
+	tests := []struct {
+		message string
+		format  string
+		keys    []string
+	}{
+		{`{"level":"info","msg":"started"}`, FormatJSON, []string{"level", "msg"}},
+		{`level=info msg="started server"`, FormatLogfmt, []string{"level", "msg"}},
+		{`{"level":"info"`, "", nil},
+		{`started server`, "", nil},
+	}
+	for _, test := range tests {
+		fields, format := ParseFields(test.message)
+		if format != test.format || (fields == nil) != (test.keys == nil) {
+			t.Errorf("%q: expected %q format, got %q %v", test.message, test.format, format, fields)
+			continue
+		}
+		if fields != nil && !reflect.DeepEqual(fields.Keys, test.keys) {
+			t.Errorf("%q: expected keys %v, got %v", test.message, test.keys, fields.Keys)
+		}
+	}
+}
+
+func TestObjectMerge(t *testing.T) {
+	obj, _ := ParseLogfmt(`a=1 b=2`)
+	other, _ := ParseLogfmt(`b=3 c=4`)
+	merged := obj.Merge(other)
+	if !reflect.DeepEqual(merged.Keys, []string{"a", "b", "c"}) || merged.Values["b"] != "2" || merged.Values["c"] != "4" {
+		t.Errorf("Unexpected merged object %v", merged)
+	}
+	if len(obj.Keys) != 2 || len(other.Keys) != 2 {
+		t.Errorf("Merged objects must not be modified")
+	}
+}
@@ -0,0 +1,154 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SyntaxError is an error in the expression. Pos is the byte offset of the error in the expression
+type SyntaxError struct {
+	Expr string
+	Pos  int
+	Msg  string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%s at position %d\n  %s\n  %s^", e.Msg, e.Pos+1, e.Expr, strings.Repeat(" ", e.Pos))
+}
+
+// parser is a recursive descent parser working directly on the expression string
+type parser struct {
+	input string
+	pos   int
+	// bareNames allows field names without the leading '.', e.g. request.path
+	bareNames bool
+}
+
+func (p *parser) errorf(pos int, format string, args ...interface{}) error {
+	return &SyntaxError{Expr: p.input, Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) skipSpaces() {
+	for p.pos < len(p.input) && strings.IndexByte(" \t\r\n", p.input[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+// peek returns the next character after spaces, or 0 at the end of the expression
+func (p *parser) peek() byte {
+	p.skipSpaces()
+	if p.pos >= len(p.input) {
+		return 0
+	}
+	return p.input[p.pos]
+}
+
+// consume skips the token if the expression continues with it
+func (p *parser) consume(token string) bool {
+	p.skipSpaces()
+	if strings.HasPrefix(p.input[p.pos:], token) {
+		p.pos += len(token)
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(token string) error {
+	if !p.consume(token) {
+		return p.errorf(p.pos, "expected '%s'", token)
+	}
+	return nil
+}
+
+var keywords = []string{"and", "or", "not", "true", "false", "null"}
+
+// consumeKeyword skips the word if the expression continues with it as a whole word
+func (p *parser) consumeKeyword(word string) bool {
+	p.skipSpaces()
+	end := p.pos + len(word)
+	if strings.HasPrefix(p.input[p.pos:], word) && (end >= len(p.input) || !isIdentChar(p.input[end])) {
+		p.pos = end
+		return true
+	}
+	return false
+}
+
+// atKeyword returns true if the expression continues with a keyword
+func (p *parser) atKeyword() bool {
+	start := p.pos
+	name := p.parseIdent()
+	p.pos = start
+	for _, keyword := range keywords {
+		if name == keyword {
+			return true
+		}
+	}
+	return false
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || (c >= '0' && c <= '9')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// parseIdent parses a name made of letters, digits, '_' and '$' at the current position,
+// returns empty string if there is no name
+func (p *parser) parseIdent() string {
+	start := p.pos
+	if p.pos < len(p.input) && isIdentStart(p.input[p.pos]) {
+		for p.pos < len(p.input) && isIdentChar(p.input[p.pos]) {
+			p.pos++
+		}
+	}
+	return p.input[start:p.pos]
+}
+
+// parseString parses a double-quoted string with Go/JSON escape sequences
+func (p *parser) parseString() (string, error) {
+	p.skipSpaces()
+	start := p.pos
+	if p.pos >= len(p.input) || p.input[p.pos] != '"' {
+		return "", p.errorf(start, "expected string")
+	}
+	p.pos++
+	for p.pos < len(p.input) && p.input[p.pos] != '"' {
+		if p.input[p.pos] == '\\' {
+			p.pos++
+		}
+		p.pos++
+	}
+	if p.pos >= len(p.input) {
+		return "", p.errorf(start, "unterminated string")
+	}
+	p.pos++
+	s, err := strconv.Unquote(p.input[start:p.pos])
+	if err != nil {
+		return "", p.errorf(start, "invalid string")
+	}
+	return s, nil
+}
+
+// parseNumber parses an integer or decimal number, optionally negative
+func (p *parser) parseNumber() (json.Number, error) {
+	p.skipSpaces()
+	start := p.pos
+	if p.pos < len(p.input) && p.input[p.pos] == '-' {
+		p.pos++
+	}
+	for p.pos < len(p.input) && (isDigit(p.input[p.pos]) || p.input[p.pos] == '.') {
+		p.pos++
+	}
+	if _, err := strconv.ParseFloat(p.input[start:p.pos], 64); err != nil {
+		return "", p.errorf(start, "invalid number")
+	}
+	return json.Number(p.input[start:p.pos]), nil
+}
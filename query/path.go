@@ -0,0 +1,309 @@
+package query
+
+import (
+	"strings"
+	"time"
+)
+
+// Names of the event metadata available in expressions as @name
+const (
+	MetaTimestamp = "timestamp"
+	MetaStream    = "stream"
+	MetaGroup     = "group"
+	MetaMessage   = "message"
+	MetaLevel     = "level"
+)
+
+// Record is a log event as seen by expressions
+type Record struct {
+	// Fields is the parsed message, nil if the message is not structured
+	Fields    *Object
+	Message   string
+	Timestamp time.Time
+	Group     string
+	Stream    string
+	Level     string
+}
+
+// valueExpr is an expression producing a value. ok is false if the value is missing
+type valueExpr interface {
+	eval(r *Record) (value interface{}, ok bool)
+	usesFields() bool
+}
+
+type step struct {
+	field   string
+	index   int
+	isIndex bool
+}
+
+// pathExpr refers to a field of the structured message, e.g. .request.headers["user-agent"] or .items[0],
+// or to the event metadata, e.g. @timestamp
+type pathExpr struct {
+	meta  string
+	steps []step
+}
+
+func (e *pathExpr) eval(r *Record) (interface{}, bool) {
+	switch e.meta {
+	case "":
+	case MetaTimestamp:
+		return r.Timestamp, true
+	case MetaStream:
+		return r.Stream, true
+	case MetaGroup:
+		return r.Group, true
+	case MetaMessage:
+		return r.Message, true
+	case MetaLevel:
+		return r.Level, true
+	}
+	if r.Fields == nil {
+		return nil, false
+	}
+	var value interface{} = r.Fields
+	for _, s := range e.steps {
+		switch v := value.(type) {
+		case *Object:
+			if s.isIndex {
+				return nil, false
+			}
+			var ok bool
+			if value, ok = v.Values[s.field]; !ok {
+				return nil, false
+			}
+		case []interface{}:
+			index := s.index
+			if index < 0 {
+				index += len(v)
+			}
+			if !s.isIndex || index < 0 || index >= len(v) {
+				return nil, false
+			}
+			value = v[index]
+		default:
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+func (e *pathExpr) usesFields() bool {
+	return e.meta == ""
+}
+
+type literalExpr struct {
+	value interface{}
+}
+
+func (e *literalExpr) eval(*Record) (interface{}, bool) {
+	return e.value, true
+}
+
+func (e *literalExpr) usesFields() bool {
+	return false
+}
+
+// alternativeExpr is jq's "a // b", which evaluates to b if a is missing or null
+type alternativeExpr struct {
+	options []valueExpr
+}
+
+func (e *alternativeExpr) eval(r *Record) (interface{}, bool) {
+	for _, option := range e.options {
+		if value, ok := option.eval(r); ok && value != nil {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+func (e *alternativeExpr) usesFields() bool {
+	for _, option := range e.options {
+		if option.usesFields() {
+			return true
+		}
+	}
+	return false
+}
+
+var metaNames = []string{MetaTimestamp, MetaStream, MetaGroup, MetaMessage, MetaLevel}
+
+// parsePath parses .field.field[index]["field"] or @name
+func (p *parser) parsePath() (valueExpr, error) {
+	p.skipSpaces()
+	start := p.pos
+	if p.consume("@") {
+		name := p.parseIdent()
+		for _, meta := range metaNames {
+			if name == meta {
+				return &pathExpr{meta: name}, nil
+			}
+		}
+		return nil, p.errorf(start, "unknown metadata '@%s', expected one of @%s", name, strings.Join(metaNames, ", @"))
+	}
+	if p.peek() != '.' {
+		return nil, p.errorf(start, "expected path starting with '.' or '@'")
+	}
+	return p.parseSteps(&pathExpr{})
+}
+
+// parseSteps parses the rest of the path, i.e. .field, [index] and ["field"] steps
+func (p *parser) parseSteps(path *pathExpr) (valueExpr, error) {
+	for {
+		switch {
+		case p.pos < len(p.input) && p.input[p.pos] == '.':
+			p.pos++
+			if p.pos < len(p.input) && p.input[p.pos] == '[' {
+				continue
+			}
+			name := p.parseIdent()
+			if name == "" {
+				if len(path.steps) == 0 && !p.continuesPath() {
+					// "." alone refers to the whole message
+					return path, nil
+				}
+				return nil, p.errorf(p.pos, "expected field name")
+			}
+			path.steps = append(path.steps, step{field: name})
+		case p.pos < len(p.input) && p.input[p.pos] == '[':
+			p.pos++
+			s, err := p.parseIndex()
+			if err != nil {
+				return nil, err
+			}
+			if err = p.expect("]"); err != nil {
+				return nil, err
+			}
+			path.steps = append(path.steps, s)
+		default:
+			return path, nil
+		}
+	}
+}
+
+// continuesPath returns true if the next character would continue the path
+func (p *parser) continuesPath() bool {
+	return p.pos < len(p.input) && (isIdentChar(p.input[p.pos]) || p.input[p.pos] == '.')
+}
+
+func (p *parser) parseIndex() (step, error) {
+	c := p.peek()
+	if c == '"' {
+		name, err := p.parseString()
+		return step{field: name}, err
+	}
+	start := p.pos
+	if c == '-' || isDigit(c) {
+		number, err := p.parseNumber()
+		if err != nil {
+			return step{}, err
+		}
+		index, err := number.Int64()
+		if err != nil {
+			return step{}, p.errorf(start, "array index must be an integer")
+		}
+		return step{index: int(index), isIndex: true}, nil
+	}
+	return step{}, p.errorf(start, "expected array index or quoted field name")
+}
+
+// parseLiteral parses a string, number, true, false or null
+func (p *parser) parseLiteral() (valueExpr, error) {
+	c := p.peek()
+	switch {
+	case c == '"':
+		s, err := p.parseString()
+		return &literalExpr{value: s}, err
+	case c == '-' || isDigit(c):
+		n, err := p.parseNumber()
+		return &literalExpr{value: n}, err
+	}
+	start := p.pos
+	switch p.parseIdent() {
+	case "true":
+		return &literalExpr{value: true}, nil
+	case "false":
+		return &literalExpr{value: false}, nil
+	case "null":
+		return &literalExpr{value: nil}, nil
+	}
+	p.pos = start
+	return nil, p.errorf(start, "expected value")
+}
+
+// parseValue parses a path or a literal, optionally followed by '//' and alternative values
+func (p *parser) parseValue() (valueExpr, error) {
+	var options []valueExpr
+	for {
+		var option valueExpr
+		var err error
+		c := p.peek()
+		switch {
+		case c == '.' || c == '@':
+			option, err = p.parsePath()
+		case p.bareNames && isIdentStart(c) && !p.atKeyword():
+			path := &pathExpr{steps: []step{{field: p.parseIdent()}}}
+			option, err = p.parseSteps(path)
+		default:
+			option, err = p.parseLiteral()
+		}
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, option)
+		if !p.consume("//") {
+			break
+		}
+	}
+	if len(options) == 1 {
+		return options[0], nil
+	}
+	return &alternativeExpr{options: options}, nil
+}
+
+// Selection is a list of value expressions, which project the event into columns
+type Selection struct {
+	columns []valueExpr
+}
+
+// ParseSelection parses comma-separated list of jq-like paths, e.g. ".request.path, .status, @timestamp".
+// Paths can use array indices (.items[0], .items[-1]), quoted field names (.headers["user-agent"]) and
+// alternatives (.user.id // "-"). Event metadata is available as @timestamp, @stream, @group, @message and @level
+func ParseSelection(expr string) (*Selection, error) {
+	p := &parser{input: expr}
+	selection := &Selection{}
+	for {
+		column, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		selection.columns = append(selection.columns, column)
+		if !p.consume(",") {
+			break
+		}
+	}
+	if p.peek() != 0 {
+		return nil, p.errorf(p.pos, "unexpected '%c'", p.input[p.pos])
+	}
+	return selection, nil
+}
+
+// UsesFields returns true if any of the columns refers to the fields of the message
+func (s *Selection) UsesFields() bool {
+	for _, column := range s.columns {
+		if column.usesFields() {
+			return true
+		}
+	}
+	return false
+}
+
+// Evaluate returns values of all the columns for the record, missing values are nil
+func (s *Selection) Evaluate(r *Record) []interface{} {
+	values := make([]interface{}, len(s.columns))
+	for i, column := range s.columns {
+		values[i], _ = column.eval(r)
+	}
+	return values
+}
@@ -0,0 +1,123 @@
+package query
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testRecord(t *testing.T) *Record {
+	fields, ok := ParseJSON(`{"request":{"path":"/api","headers":{"user-agent":"curl"}},"status":503,"items":[1,2,3],"user":null}`)
+	if !ok {
+		t.Fatalf("Failed to parse test message")
+	}
+	return &Record{
+		Fields:    fields,
+		Message:   "message",
+		Timestamp: time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC),
+		Group:     "/aws/lambda/api",
+		Stream:    "2026/10/19/[$LATEST]abc",
+		Level:     "error",
+	}
+}
+
+func TestSelection(t *testing.T) {
+	record := testRecord(t)
+	tests := []struct {
+		expr     string
+		expected []interface{}
+	}{
+		{".request.path, .status", []interface{}{"/api", json.Number("503")}},
+		{`.request.headers["user-agent"]`, []interface{}{"curl"}},
+		{`.request["headers"].missing`, []interface{}{nil}},
+		{".items[0], .items[-1], .items[3]", []interface{}{json.Number("1"), json.Number("3"), nil}},
+		{".status.code, .request[0]", []interface{}{nil, nil}},
+		{`.user // .missing // "anonymous"`, []interface{}{"anonymous"}},
+		{`.status // 0, true, null`, []interface{}{json.Number("503"), true, nil}},
+		{"@timestamp, @stream, @group, @message, @level", []interface{}{record.Timestamp, record.Stream, record.Group, "message", "error"}},
+		{" .items ", []interface{}{[]interface{}{json.Number("1"), json.Number("2"), json.Number("3")}}},
+	}
+	for _, test := range tests {
+		selection, err := ParseSelection(test.expr)
+		if err != nil {
+			t.Errorf("'%s': unexpected error %v", test.expr, err)
+			continue
+		}
+		actual := selection.Evaluate(record)
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("'%s'\nExpected: %v\n  Actual: %v", test.expr, test.expected, actual)
+		}
+	}
+}
+
+func TestSelectionWholeMessage(t *testing.T) {
+	record := testRecord(t)
+	selection, err := ParseSelection(".")
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if actual := selection.Evaluate(record); !reflect.DeepEqual(actual[0], record.Fields) {
+		t.Errorf("Expected whole message, got %v", actual)
+	}
+	record.Fields = nil
+	if actual := selection.Evaluate(record); actual[0] != nil {
+		t.Errorf("Expected nil for unstructured message, got %v", actual)
+	}
+}
+
+func TestSelectionUsesFields(t *testing.T) {
+	tests := map[string]bool{
+		"@timestamp, @message":    false,
+		"@stream, .status":        true,
+		`@level // .level // "-"`: true,
+	}
+	for expr, expected := range tests {
+		selection, err := ParseSelection(expr)
+		if err != nil {
+			t.Errorf("'%s': unexpected error %v", expr, err)
+			continue
+		}
+		if selection.UsesFields() != expected {
+			t.Errorf("'%s': expected %v", expr, expected)
+		}
+	}
+}
+
+func TestSelectionErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		pos  int
+		msg  string
+	}{
+		{"request.path", 0, "expected value"},
+		{".request.", 9, "expected field name"},
+		{".items[x]", 7, "expected array index"},
+		{".items[1.5]", 7, "array index must be an integer"},
+		{".items[1", 8, "expected ']'"},
+		{`.a["b]`, 3, "unterminated string"},
+		{"@time", 0, "unknown metadata '@time'"},
+		{".a .b", 3, "unexpected '.'"},
+		{".a,", 3, "expected value"},
+	}
+	for _, test := range tests {
+		_, err := ParseSelection(test.expr)
+		syntaxErr, ok := err.(*SyntaxError)
+		if !ok {
+			t.Errorf("'%s': expected syntax error, got %v", test.expr, err)
+			continue
+		}
+		if syntaxErr.Pos != test.pos || !strings.HasPrefix(syntaxErr.Msg, test.msg) {
+			t.Errorf("'%s': expected '%s' at %d, got '%s' at %d", test.expr, test.msg, test.pos, syntaxErr.Msg, syntaxErr.Pos)
+		}
+	}
+}
+
+func TestSyntaxErrorMessage(t *testing.T) {
+	_, err := ParseSelection(".a, .b[")
+	expected := "expected array index or quoted field name at position 8\n  .a, .b[\n         ^"
+	if err == nil || err.Error() != expected {
+		t.Errorf("\nExpected: %q\n  Actual: %v", expected, err)
+	}
+}
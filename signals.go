@@ -0,0 +1,25 @@
+//go:build !windows
+// +build !windows
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/uaraven/cwltail/cwlogs"
+)
+
+// reportOnSignal prints the summary to stderr every time cwltail receives SIGUSR1
+func reportOnSignal(s *summary) {
+	requests := make(chan os.Signal, 1)
+	signal.Notify(requests, syscall.SIGUSR1)
+	go func() {
+		for range requests {
+			fmt.Fprint(os.Stderr, s.report(time.Now(), cwlogs.GetAPIStats()))
+		}
+	}()
+}
@@ -0,0 +1,4 @@
+package main
+
+// reportOnSignal does nothing, there is no SIGUSR1 on Windows
+func reportOnSignal(*summary) {}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/uaraven/cwltail/cwlogs"
+	"github.com/uaraven/cwltail/ui"
+)
+
+// statusRefresh is how often the status line is redrawn
+const statusRefresh = time.Second
+
+// statusTracker follows the received events for the status line
+type statusTracker struct {
+	// received is the number of events received since the last refresh
+	received  int
+	refreshed time.Time
+	rate      float64
+	// lastEvent is when the last event was received, newest is the latest timestamp among the events
+	lastEvent time.Time
+	newest    time.Time
+}
+
+func newStatusTracker(now time.Time) *statusTracker {
+	return &statusTracker{refreshed: now}
+}
+
+// add counts the received event, notes about repeated messages count as all the events they replaced,
+// like in the summary
+func (t *statusTracker) add(event cwlogs.CWLEvent, now time.Time) {
+	if note, isNote := event.(*cwlogs.RepeatNote); isNote {
+		t.received += note.Count
+	} else {
+		t.received++
+	}
+	t.lastEvent = now
+	if event.Timestamp().After(t.newest) {
+		t.newest = event.Timestamp()
+	}
+}
+
+func formatAge(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	if d < time.Minute {
+		return d.Round(100 * time.Millisecond).String()
+	}
+	return d.Round(time.Second).String()
+}
+
+// render updates the rate and creates the text of the status line
+func (t *statusTracker) render(now time.Time, api cwlogs.APIStats) *ui.StyledText {
+	if elapsed := now.Sub(t.refreshed); elapsed > 0 {
+		t.rate = float64(t.received) / elapsed.Seconds()
+	}
+	t.received = 0
+	t.refreshed = now
+	text := fmt.Sprintf(" %d streams │ %.1f events/s │ ", api.Streams, t.rate)
+	if t.lastEvent.IsZero() {
+		text += "no events yet"
+	} else {
+		text += fmt.Sprintf("last event %s ago │ lag %s", formatAge(now.Sub(t.lastEvent)), formatAge(now.Sub(t.newest)))
+	}
+	text += fmt.Sprintf(" │ poll %s ", cwlogs.PollInterval)
+	line := ui.NewStyledText("").Append(text, ui.StatusLineStyle)
+	switch {
+	case api.Throttled:
+		line.Append(" throttled ", ui.StatusErrorStyle)
+	case api.LastError != "":
+		line.Append(" error: "+api.LastError+" ", ui.StatusErrorStyle)
+	}
+	return line
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/uaraven/cwltail/cwlogs"
+	"github.com/uaraven/cwltail/ui"
+)
+
+const (
+	// summaryTopSize is the number of streams and errors listed in the summary
+	summaryTopSize = 10
+	// summaryHeaderStyle is the style of the section names of the summary
+	summaryHeaderStyle = "+b"
+)
+
+type errorCount struct {
+	label string
+	count int
+}
+
+// summary collects statistics of the received events for the report displayed on exit or on SIGUSR1
+type summary struct {
+	sync.Mutex
+	started  time.Time
+	events   int
+	matched  int
+	groups   map[string]int
+	streams  map[string]int
+	levels   map[cwlogs.Level]int
+	errors   map[string]*errorCount
+	repeated int
+}
+
+func newSummary(started time.Time) *summary {
+	return &summary{
+		started: started,
+		groups:  make(map[string]int),
+		streams: make(map[string]int),
+		levels:  make(map[cwlogs.Level]int),
+		errors:  make(map[string]*errorCount),
+	}
+}
+
+// add counts the event after it was processed, matched is true if the event passed the filters.
+// Notes about repeated messages count as the number of repetitions of the repeated event. Nothing is collected by nil summary,
+// which is used when --summary is not set
+func (s *summary) add(event cwlogs.CWLEvent, matched bool) {
+	if s == nil {
+		return
+	}
+	s.Lock()
+	defer s.Unlock()
+	count := 1
+	// repeated errors are counted by the message of the repeated event, not the one of the note
+	original := event
+	if note, isNote := event.(*cwlogs.RepeatNote); isNote {
+		count = note.Count
+		s.repeated += count
+		original = note.CWLEvent
+	}
+	if fingerprint, label, ok := cwlogs.ErrorFingerprint(original.Message(), original.Level()); ok {
+		if e, exists := s.errors[fingerprint]; exists {
+			e.count += count
+		} else {
+			s.errors[fingerprint] = &errorCount{label: label, count: count}
+		}
+	}
+	s.events += count
+	if matched {
+		s.matched += count
+	}
+	s.groups[event.LogGroup()] += count
+	s.streams[streamKey(event)] += count
+	s.levels[event.Level()] += count
+}
+
+// topCounts returns names with the highest counts, at most n of them
+func topCounts(counts map[string]int, n int) []string {
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if counts[names[i]] != counts[names[j]] {
+			return counts[names[i]] > counts[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	if len(names) > n {
+		names = names[:n]
+	}
+	return names
+}
+
+func heading(title string) string {
+	return ui.NewStyledText("").Append(title, summaryHeaderStyle).Render() + "\n"
+}
+
+func formatBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	value := float64(bytes) / unit
+	for _, suffix := range []string{"KiB", "MiB", "GiB"} {
+		if value < unit {
+			return fmt.Sprintf("%.1f %s", value, suffix)
+		}
+		value /= unit
+	}
+	return fmt.Sprintf("%.1f TiB", value)
+}
+
+func writeCounts(sb *strings.Builder, title string, counts map[string]int) {
+	sb.WriteString(heading(title))
+	names := topCounts(counts, summaryTopSize)
+	width := 0
+	for _, name := range names {
+		if len(name) > width {
+			width = len(name)
+		}
+	}
+	for _, name := range names {
+		fmt.Fprintf(sb, "  %-*s %8d\n", width, name, counts[name])
+	}
+	if len(counts) > len(names) {
+		fmt.Fprintf(sb, "  … and %d more\n", len(counts)-len(names))
+	}
+}
+
+// report formats the summary of the events received until now, together with API usage
+func (s *summary) report(now time.Time, api cwlogs.APIStats) string {
+	s.Lock()
+	defer s.Unlock()
+	elapsed := now.Sub(s.started)
+	rate := 0.0
+	if elapsed > 0 {
+		rate = float64(s.events) / elapsed.Seconds()
+	}
+	var sb strings.Builder
+	sb.WriteString(heading(fmt.Sprintf("Summary after %s", elapsed.Round(time.Second))))
+	fmt.Fprintf(&sb, "  %d events, %.2f/s, %d displayed, %d filtered out", s.events, rate, s.matched, s.events-s.matched)
+	if s.repeated > 0 {
+		fmt.Fprintf(&sb, ", %d collapsed repeats", s.repeated)
+	}
+	sb.WriteString("\n")
+	var levels []string
+	for level := cwlogs.LevelFatal; level >= cwlogs.LevelUnknown; level-- {
+		if count := s.levels[level]; count > 0 {
+			levels = append(levels, fmt.Sprintf("%s %d", level, count))
+		}
+	}
+	if len(levels) > 0 {
+		sb.WriteString("  " + strings.Join(levels, ", ") + "\n")
+	}
+	fmt.Fprintf(&sb, "  %d API calls", api.Calls)
+	if api.Errors > 0 {
+		fmt.Fprintf(&sb, " (%d failed)", api.Errors)
+	}
+	fmt.Fprintf(&sb, ", %d events and %s of messages received\n", api.Events, formatBytes(api.Bytes))
+	if len(s.groups) > 1 {
+		writeCounts(&sb, "Groups", s.groups)
+	}
+	if len(s.streams) > 0 {
+		writeCounts(&sb, "Streams", s.streams)
+	}
+	if len(s.errors) > 0 {
+		sb.WriteString(heading("Top errors"))
+		counts := make(map[string]int, len(s.errors))
+		for fingerprint, e := range s.errors {
+			counts[fingerprint] = e.count
+		}
+		for _, fingerprint := range topCounts(counts, summaryTopSize) {
+			fmt.Fprintf(&sb, "  %8d  %s  %s\n", counts[fingerprint], fingerprint, s.errors[fingerprint].label)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/uaraven/cwltail/cwlogs"
+)
+
+var summaryStart = time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+
+type testEvent struct {
+	group   string
+	stream  string
+	message string
+	level   cwlogs.Level
+}
+
+func (e *testEvent) EventID() string             { return e.stream + e.message }
+func (e *testEvent) Timestamp() time.Time        { return summaryStart }
+func (e *testEvent) Message() string             { return e.message }
+func (e *testEvent) LogGroup() string            { return e.group }
+func (e *testEvent) LogStream() string           { return e.stream }
+func (e *testEvent) ShortStreamName() string     { return e.stream }
+func (e *testEvent) Level() cwlogs.Level         { return e.level }
+func (e *testEvent) SetLevel(level cwlogs.Level) { e.level = level }
+
+func TestTopCounts(t *testing.T) {
+	counts := map[string]int{"c": 2, "a": 5, "b": 2, "d": 1}
+	tests := []struct {
+		n        int
+		expected []string
+	}{
+		{10, []string{"a", "b", "c", "d"}},
+		{2, []string{"a", "b"}},
+		{0, []string{}},
+	}
+	for _, test := range tests {
+		if actual := topCounts(counts, test.n); !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("%d: expected %v, got %v", test.n, test.expected, actual)
+		}
+	}
+}
+
+func TestSummaryAdd(t *testing.T) {
+	info := &testEvent{group: "g1", stream: "s1", message: "INFO started", level: cwlogs.LevelInfo}
+	failed := &testEvent{group: "g1", stream: "s1", message: "ERROR order 12 failed", level: cwlogs.LevelError}
+	other := &testEvent{group: "g2", stream: "s2", message: "ERROR order 15 failed", level: cwlogs.LevelError}
+	repeated := &cwlogs.RepeatNote{CWLEvent: failed, Count: 4, Last: summaryStart}
+	tests := []struct {
+		name      string
+		events    []cwlogs.CWLEvent
+		matched   []bool
+		received  int
+		displayed int
+		repeats   int
+		streams   map[string]int
+		errors    int
+		distinct  int
+	}{
+		{"plain", []cwlogs.CWLEvent{info, info}, []bool{true, false}, 2, 1, 0, map[string]int{"g1/s1": 2}, 0, 0},
+		{"errors with masked numbers", []cwlogs.CWLEvent{failed, other}, []bool{true, true}, 2, 2, 0,
+			map[string]int{"g1/s1": 1, "g2/s2": 1}, 2, 1},
+		// notes count as the repeated events, including the fingerprint of the repeated error
+		{"repeat note", []cwlogs.CWLEvent{failed, repeated}, []bool{true, true}, 5, 5, 4, map[string]int{"g1/s1": 5}, 5, 1},
+	}
+	for _, test := range tests {
+		s := newSummary(summaryStart)
+		for i, event := range test.events {
+			s.add(event, test.matched[i])
+		}
+		if s.events != test.received || s.matched != test.displayed || s.repeated != test.repeats {
+			t.Errorf("%s: expected %d events, %d matched, %d repeated, got %d, %d, %d", test.name,
+				test.received, test.displayed, test.repeats, s.events, s.matched, s.repeated)
+		}
+		if !reflect.DeepEqual(s.streams, test.streams) {
+			t.Errorf("%s: expected streams %v, got %v", test.name, test.streams, s.streams)
+		}
+		errors := 0
+		for _, e := range s.errors {
+			errors += e.count
+		}
+		if errors != test.errors || len(s.errors) != test.distinct {
+			t.Errorf("%s: expected %d errors of %d kinds, got %d of %d", test.name, test.errors, test.distinct,
+				errors, len(s.errors))
+		}
+	}
+
+	var disabled *summary
+	disabled.add(info, true)
+}
+
+func TestSummaryReport(t *testing.T) {
+	s := newSummary(summaryStart)
+	failed := &testEvent{group: "g1", stream: "s1", message: "ERROR order 12 failed", level: cwlogs.LevelError}
+	s.add(&testEvent{group: "g1", stream: "s1", message: "INFO started", level: cwlogs.LevelInfo}, false)
+	s.add(failed, true)
+	s.add(&cwlogs.RepeatNote{CWLEvent: failed, Count: 2, Last: summaryStart}, true)
+	s.add(&testEvent{group: "g2", stream: "s2", message: "ERROR order 15 failed", level: cwlogs.LevelError}, true)
+
+	report := s.report(summaryStart.Add(10*time.Second), cwlogs.APIStats{Calls: 7, Errors: 1, Events: 5, Bytes: 2048})
+	for _, expected := range []string{
+		"Summary after 10s",
+		"  5 events, 0.50/s, 4 displayed, 1 filtered out, 2 collapsed repeats\n",
+		"  error 4, info 1\n",
+		"  7 API calls (1 failed), 5 events and 2.0 KiB of messages received\n",
+		"Groups",
+		"  g1        4\n",
+		"Streams",
+		"  g1/s1        4\n",
+		"Top errors",
+		"ERROR order # failed\n",
+		"         4  ",
+	} {
+		if !strings.Contains(report, expected) {
+			t.Errorf("Expected %q in the report:\n%s", expected, report)
+		}
+	}
+	if strings.Index(report, "g1/s1") > strings.Index(report, "g2/s2") {
+		t.Errorf("Streams must be ordered by the number of events:\n%s", report)
+	}
+}
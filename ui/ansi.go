@@ -8,15 +8,8 @@ import (
 )
 
 const (
-	escape        = "\033["
-	finalizer     = "m" // change color ansi code
-	normal        = "0;"
-	bold          = "1;"
-	dim           = "2;"
-	underline     = "4;"
-	blink         = "5;"
-	inverse       = "7;"
-	strikethrough = "9;"
+	escape    = "\033["
+	finalizer = "m" // change color ansi code
 
 	// Reset is an ANSI sequence to reset colors to defaults
 	Reset = "\033[39;49m"
@@ -199,9 +192,8 @@ func ColorFunc(color string) ColorizerFunc {
 			return s
 		}
 	}
-	code := ColorCode(color)
 	return func(s string) string {
-		return code + s
+		return ColorCode(color) + s
 	}
 }
 
@@ -229,7 +221,7 @@ func ansiCodesForWrapping(styleCode string) (string, string) {
 	} else {
 		resetCode = ResetBg
 	}
-	return code, resetCode
+	return code, adaptSequence(resetCode)
 }
 
 // ColorWrap sets the style for the passed string and then resets it to default
@@ -241,28 +233,28 @@ func ColorWrap(text string, style string) string {
 	return code + text + resetCode
 }
 
-// ColorWrapFunc returns a function that sets the style for the passed string and then resets it to default
+// ColorWrapFunc returns a function that sets the style for the passed string and then resets it to default.
+// Escape codes are calculated on every call, so the function follows changes of the color level
 func ColorWrapFunc(style string) ColorizerFunc {
 	if style == "" {
 		return func(s string) string {
 			return s
 		}
 	}
-	code, resetCode := ansiCodesForWrapping(style)
 	return func(s string) string {
-		return code + s + resetCode
+		return ColorWrap(s, style)
 	}
 }
 
 func Color256Wrap(text string, style string) string {
-	colors = strings.Split(style, ":")
+	colors := strings.Split(style, ":")
 	fgColor := NameToAnsi256(colors[0])
 	bgColor := -1
 	if len(colors) > 1 {
 		bgColor = NameToAnsi256(colors[1])
 	}
 	if fgColor < 0 && bgColor < 0 {
-		return Reset + text
+		return adaptSequence(Reset) + text
 	}
 	result := bytes.NewBufferString("\033[")
 	if fgColor >= 0 {
@@ -273,128 +265,81 @@ func Color256Wrap(text string, style string) string {
 	}
 	result.Truncate(result.Len() - 1)
 	result.WriteString("m")
-	result.WriteString(text)
-	result.WriteString(Reset)
-	return result.String()
+	return adaptSequence(result.String()) + text + adaptSequence(Reset)
 }
 
-func parseAttrs(attrs string, front bool) string {
+func validateAttrs(attrs string) error {
 	if attrs == "" {
-		return ""
+		return nil
 	}
-	var buf = bytes.NewBufferString("")
 	if attrs[0] == '#' {
-		if front {
-			buf.WriteString("38;")
-		} else {
-			buf.WriteString("48;")
+		if len(attrs) != 7 {
+			return fmt.Errorf("invalid color '%s', expected #RRGGBB", attrs)
 		}
-		buf.WriteString(colorRGB(int(hexToRGB(attrs))))
-		return buf.String()
-	}
-	var c256 bool
-	attrGroup := strings.Split(attrs, "+")
-	var color string
-	code, ok := colorsTerm[attrGroup[0]]
-	if !ok {
-		if code, ok = colorNames256[attrGroup[0]]; !ok {
-			color = "9"
-		} else {
-			color = "5;" + code
-			c256 = true
+		if _, err := strconv.ParseUint(attrs[1:], 16, 32); err != nil {
+			return fmt.Errorf("invalid color '%s', expected #RRGGBB", attrs)
 		}
-	} else {
-		color = code
+		return nil
 	}
-	var style string
-	if len(attrGroup) > 1 && !c256 {
-		style = attrGroup[1]
+	attrGroup := strings.Split(attrs, "+")
+	if len(attrGroup) > 2 {
+		return fmt.Errorf("invalid style '%s'", attrs)
 	}
-	var colorBase string
-	if c256 {
-		if front {
-			colorBase = "38;"
-		} else {
-			colorBase = "48;"
+	if attrGroup[0] != "" {
+		_, term := colorsTerm[attrGroup[0]]
+		_, c256 := colorNames256[attrGroup[0]]
+		if !term && !c256 {
+			return fmt.Errorf("unknown color '%s'", attrGroup[0])
 		}
-	} else if strings.Contains(style, "h") {
-		colorBase = brightColorCode[front]
-	} else {
-		colorBase = colorCode[front]
 	}
-	buf.WriteString(colorBase)
-	buf.WriteString(color)
-	buf.WriteString(";")
-	if len(style) > 0 && front {
-		if strings.Contains(style, "b") {
-			buf.WriteString(bold)
-		}
-		if strings.Contains(style, "d") {
-			buf.WriteString(dim)
-		}
-		if strings.Contains(style, "B") {
-			buf.WriteString(blink)
-		}
-		if strings.Contains(style, "u") {
-			buf.WriteString(underline)
-		}
-		if strings.Contains(style, "i") {
-			buf.WriteString(inverse)
-		}
-		if strings.Contains(style, "s") {
-			buf.WriteString(strikethrough)
+	if len(attrGroup) > 1 {
+		for _, a := range attrGroup[1] {
+			if !strings.ContainsRune(styleAttrLetters, a) {
+				return fmt.Errorf("unknown style attribute '%c' in '%s'", a, attrs)
+			}
 		}
 	}
-	return buf.String()
+	return nil
 }
 
-// ColorCode returns the ANSI color color code for style.
-func ColorCode(styleCode string) string {
-	if len(styleCode) == 0 {
-		return Reset
-	}
-	style := strings.ToLower(styleCode)
-	if style == "reset" {
-		return Reset
+// ValidateStyle checks that the style can be converted to ANSI codes, reporting unknown colors and attributes
+func ValidateStyle(styleCode string) error {
+	style := strings.ToLower(strings.TrimSpace(styleCode))
+	if style == "" || style == "reset" {
+		return nil
 	}
 	fgBg := strings.Split(style, ":")
-	fgAttrs := parseAttrs(fgBg[0], true)
-	var bgAttrs string
-	if len(fgBg) > 1 {
-		bgAttrs = parseAttrs(fgBg[1], false)
+	if len(fgBg) > 2 {
+		return fmt.Errorf("invalid style '%s', expected foreground:background", styleCode)
 	}
-	buf := bytes.NewBufferString(escape)
-	buf.WriteString(fgAttrs)
-	if bgAttrs != "" {
-		buf.WriteString(bgAttrs)
+	for _, attrs := range fgBg {
+		if err := validateAttrs(attrs); err != nil {
+			return err
+		}
 	}
-	buf.Truncate(buf.Len() - 1)
-	buf.WriteString(finalizer)
-	return buf.String()
+	return nil
 }
 
-func Color256Code(styleCode string) string {
-	if len(styleCode) == 0 {
+// styleSequence returns the ANSI sequence which switches from default style to the style
+func styleSequence(styleCode string) string {
+	codes := transition(Style{}, ParseStyle(styleCode))
+	if len(codes) == 0 {
 		return Reset
 	}
-	style := strings.ToLower(styleCode)
-	if style == "reset" {
+	return escape + strings.Join(codes, ";") + finalizer
+}
+
+// ColorCode returns the ANSI color color code for style.
+// Colors are downgraded to the ones supported by the current color level
+func ColorCode(styleCode string) string {
+	return adaptSequence(styleSequence(styleCode))
+}
+
+func Color256Code(styleCode string) string {
+	if ParseStyle(styleCode).IsEmpty() {
 		return Reset
 	}
-	fgBg := strings.Split(style, ":")
-	fgAttrs := parseAttrs(fgBg[0], true)
-	var bgAttrs string
-	if len(fgBg) > 1 {
-		bgAttrs = parseAttrs(fgBg[1], false)
-	}
-	buf := bytes.NewBufferString(escape)
-	buf.WriteString(fgAttrs)
-	if bgAttrs != "" {
-		buf.WriteString(bgAttrs)
-	}
-	buf.Truncate(buf.Len() - 1)
-	buf.WriteString(finalizer)
-	return buf.String()
+	return adaptSequence(styleSequence(styleCode))
 }
 
 // RGB creates int color from three components
@@ -449,5 +394,5 @@ func ColorRGB(fg int, bg int) string {
 	}
 	buf.Truncate(buf.Len() - 1)
 	buf.WriteRune('m')
-	return buf.String()
+	return adaptSequence(buf.String())
 }
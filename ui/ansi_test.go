@@ -77,3 +77,10 @@ func TestColorize256(t *testing.T) {
 		t.Errorf("\nExpected: %v\n  Actual: %v", expected, actual)
 	}
 }
+
+func Test256ColorAttributes(t *testing.T) {
+	col := ColorCode("plum+b:grey23")
+	if col != "\033[38;5;183;1;48;5;237m" {
+		t.Errorf("Expected: \\033[38;5;183;1;48;5;237m\n  Actual: %q", col)
+	}
+}
@@ -0,0 +1,103 @@
+package ui
+
+// ContextStyle is the style of context lines, which are displayed around matching lines
+var ContextStyle = "+d"
+
+// ContextSeparatorStyle is the style of separators between groups of lines which are not adjacent
+var ContextSeparatorStyle = "cyan"
+
+// lineRing is a fixed-size ring buffer of lines
+type lineRing struct {
+	lines []*Line
+	start int
+	count int
+}
+
+// push adds the line to the buffer, returns true if the oldest line was dropped to make space for it
+func (r *lineRing) push(line *Line) bool {
+	if len(r.lines) == 0 {
+		return true
+	}
+	if r.count < len(r.lines) {
+		r.lines[(r.start+r.count)%len(r.lines)] = line
+		r.count++
+		return false
+	}
+	r.lines[r.start] = line
+	r.start = (r.start + 1) % len(r.lines)
+	return true
+}
+
+// drain returns buffered lines from the oldest to the newest and empties the buffer
+func (r *lineRing) drain() []*Line {
+	result := make([]*Line, 0, r.count)
+	for i := 0; i < r.count; i++ {
+		result = append(result, r.lines[(r.start+i)%len(r.lines)])
+	}
+	r.start = 0
+	r.count = 0
+	return result
+}
+
+type streamContext struct {
+	before    lineRing
+	afterLeft int
+	printed   bool
+	skipped   bool
+}
+
+// ContextLines keeps recent lines of each stream and decides which of them are displayed as context
+// around matching lines, like grep -A, -B and -C do
+type ContextLines struct {
+	before  int
+	after   int
+	streams map[string]*streamContext
+}
+
+// NewContextLines creates context tracker displaying up to before lines before each matching line and
+// up to after lines after it
+func NewContextLines(before int, after int) *ContextLines {
+	return &ContextLines{
+		before:  before,
+		after:   after,
+		streams: make(map[string]*streamContext),
+	}
+}
+
+func contextLine(line *Line) *Line {
+	line.Context = true
+	return line
+}
+
+// Add adds the next line of the stream and returns lines which should be displayed now, in order.
+// Context lines are dimmed, groups of lines which are not adjacent in the stream are separated with "--"
+func (c *ContextLines) Add(stream string, line *Line, matched bool) []*Line {
+	s, ok := c.streams[stream]
+	if !ok {
+		s = &streamContext{before: lineRing{lines: make([]*Line, c.before)}}
+		c.streams[stream] = s
+	}
+	if !matched {
+		if s.afterLeft > 0 {
+			s.afterLeft--
+			return []*Line{contextLine(line)}
+		}
+		if s.before.push(line) {
+			s.skipped = true
+		}
+		return nil
+	}
+	var result []*Line
+	if s.printed && s.skipped {
+		separator := NewStyledText("").Append("--", ContextSeparatorStyle)
+		result = append(result, &Line{Message: separator, Separator: true})
+	}
+	for _, before := range s.before.drain() {
+		result = append(result, contextLine(before))
+	}
+	result = append(result, line)
+	s.printed = true
+	s.skipped = false
+	s.afterLeft = c.after
+	return result
+}
@@ -0,0 +1,57 @@
+package ui
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// runContext feeds lines to the context tracker, lines starting with '*' are matching,
+// returns displayed lines with context lines prefixed with '.'
+func runContext(c *ContextLines, stream string, lines []string, output *[]string) {
+	for _, line := range lines {
+		matched := strings.HasPrefix(line, "*")
+		for _, displayed := range c.Add(stream, &Line{Stream: stream, Message: NewStyledText(line)}, matched) {
+			text := displayed.Message.Text()
+			if displayed.Context {
+				text = "." + text
+			}
+			*output = append(*output, text)
+		}
+	}
+}
+
+func TestContextLines(t *testing.T) {
+	tests := []struct {
+		before   int
+		after    int
+		lines    []string
+		expected []string
+	}{
+		{0, 0, []string{"a", "*b", "c", "*d", "*e"}, []string{"*b", "--", "*d", "*e"}},
+		{1, 1, []string{"a", "b", "*c", "d", "e", "f", "*g", "h"}, []string{".b", "*c", ".d", "--", ".f", "*g", ".h"}},
+		{2, 0, []string{"a", "*b", "c", "*d"}, []string{".a", "*b", ".c", "*d"}},
+		{0, 2, []string{"*a", "b", "*c", "d", "e", "f"}, []string{"*a", ".b", "*c", ".d", ".e"}},
+		{1, 1, []string{"a", "*b", "c", "d", "*e"}, []string{".a", "*b", ".c", ".d", "*e"}},
+	}
+	for i, test := range tests {
+		var output []string
+		runContext(NewContextLines(test.before, test.after), "s", test.lines, &output)
+		if !reflect.DeepEqual(output, test.expected) {
+			t.Errorf("%d\nExpected: %v\n  Actual: %v", i, test.expected, output)
+		}
+	}
+}
+
+func TestContextLinesPerStream(t *testing.T) {
+	var output []string
+	c := NewContextLines(1, 1)
+	runContext(c, "s1", []string{"a1"}, &output)
+	runContext(c, "s2", []string{"a2", "*b2"}, &output)
+	runContext(c, "s1", []string{"*b1", "c1"}, &output)
+	runContext(c, "s2", []string{"c2", "d2"}, &output)
+	expected := []string{".a2", "*b2", ".a1", "*b1", ".c1", ".c2"}
+	if !reflect.DeepEqual(output, expected) {
+		t.Errorf("\nExpected: %v\n  Actual: %v", expected, output)
+	}
+}
@@ -0,0 +1,182 @@
+package ui
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/uaraven/cwltail/query"
+)
+
+// Log formats recognized by the format detector
+const (
+	FormatAuto   = "auto"
+	FormatPlain  = "plain"
+	FormatJSON   = "json"
+	FormatLogfmt = "logfmt"
+	FormatJava   = "java"
+	FormatPython = "python"
+	FormatAccess = "access"
+)
+
+// Formats lists all the formats which can be passed to NewFormatDetector
+var Formats = []string{FormatAuto, FormatPlain, FormatJSON, FormatLogfmt, FormatJava, FormatPython, FormatAccess}
+
+// DefaultFormatSamples is the number of the first events of each stream used to detect the format
+const DefaultFormatSamples = 10
+
+var (
+	accessLogPattern = regexp.MustCompile(`^\S+ \S+ \S+ \[[^]]+] "[A-Z]+ \S+(?: HTTP/[\d.]+)?" \d{3} `)
+	javaPatterns     = []*regexp.Regexp{
+		regexp.MustCompile(`(?m)^\s+at [\w$.<>/]+\(`),
+		regexp.MustCompile(`(?m)^(?:Exception in thread "[^"]*" |Caused by: )[\w$.]+`),
+		regexp.MustCompile(`^(?:\d{4}-\d{2}-\d{2}[ T])?\d{2}:\d{2}:\d{2}[.,]\d{3}\s.*\b(?:TRACE|DEBUG|INFO|WARN|ERROR)\b.*\b(?:[a-z_$][\w$]*\.)+[A-Z][\w$]*\b`),
+	}
+	pythonPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`Traceback \(most recent call last\):`),
+		regexp.MustCompile(`(?m)^\s+File "[^"]+", line \d+, in `),
+		regexp.MustCompile(`^(?:DEBUG|INFO|WARNING|ERROR|CRITICAL):[\w.]+:`),
+		regexp.MustCompile(`^\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2},\d{3} - [\w.]+ - (?:DEBUG|INFO|WARNING|ERROR|CRITICAL) - `),
+	}
+)
+
+// Format-specific highlighters use basic colors, so they look fine on both dark and light backgrounds
+var formatThemes = map[string]*Theme{
+	FormatJSON: MustCompileTheme(ThemeDefinition{Rules: []ThemeRule{
+		{Pattern: `("(?:[^"\\]|\\.)*")\s*:`, Groups: []string{"blue+h"}},
+		{Pattern: `"(?:[^"\\]|\\.)*"`, Style: "green"},
+		{Pattern: `-?\b\d+(?:\.\d+)?(?:[eE][+-]?\d+)?\b`, Style: "yellow"},
+		{Pattern: `\b(?:true|false|null)\b`, Style: "magenta", CaseSensitive: true},
+	}}),
+	FormatLogfmt: MustCompileTheme(ThemeDefinition{Rules: []ThemeRule{
+		{Pattern: `([\w.\-/]+)=("(?:[^"\\]|\\.)*"|\S*)`, Groups: []string{"blue+h", "green"}},
+	}}),
+	FormatAccess: MustCompileTheme(ThemeDefinition{Rules: []ThemeRule{
+		{Pattern: `^(\S+) \S+ (\S+) \[([^]]+)] "(\S+) (\S+)[^"]*" `, Groups: []string{"cyan", "blue", "yellow", "magenta+b", "blue+h"}},
+		{Pattern: `(?<=" )2\d\d\b`, Style: "green"},
+		{Pattern: `(?<=" )3\d\d\b`, Style: "cyan"},
+		{Pattern: `(?<=" )4\d\d\b`, Style: "yellow+b"},
+		{Pattern: `(?<=" )5\d\d\b`, Style: "red+b"},
+		{Pattern: `"[^"]*"`, Style: "+d"},
+	}}),
+	FormatJava: MustCompileTheme(ThemeDefinition{Rules: []ThemeRule{
+		{Pattern: `(?m)^(?:\d{4}-\d{2}-\d{2}[ T])?\d{2}:\d{2}:\d{2}[.,]\d{3}`, Style: "yellow"},
+		{Pattern: `(?m)^\s+(at) ([\w$.<>/]+)\(([^)]*)\)`, Groups: []string{"+d", "cyan", "+d"}, CaseSensitive: true},
+		{Pattern: `\[([^]]+)]`, Groups: []string{"cyan"}},
+		{Pattern: `\b(?:TRACE|DEBUG|INFO|WARN|ERROR|FATAL)\b`, Style: "magenta", CaseSensitive: true},
+		{Pattern: `(?m)^(?:Caused by|Exception in thread "[^"]*")`, Style: "red+b", CaseSensitive: true},
+		{Pattern: `\b(?:[\w$]+\.)*[\w$]*(?:Exception|Error)\b`, Style: "red", CaseSensitive: true},
+		{Pattern: `\b(?:[a-z_$][\w$]*\.)+[A-Z][\w$]*\b`, Style: "blue+h", CaseSensitive: true},
+	}}),
+	FormatPython: MustCompileTheme(ThemeDefinition{Rules: []ThemeRule{
+		{Pattern: `\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2},\d{3}`, Style: "yellow"},
+		{Pattern: `Traceback \(most recent call last\):`, Style: "red+b", CaseSensitive: true},
+		{Pattern: `(?m)^\s+File "([^"]+)", line (\d+), in (\S+)`, Groups: []string{"cyan", "yellow", "blue+h"}, CaseSensitive: true},
+		{Pattern: `(?m)^((?:\w+\.)*\w*(?:Error|Exception|Warning|Interrupt|Exit))(?::|$)`, Groups: []string{"red+b"}, CaseSensitive: true},
+		{Pattern: `(?m)^(DEBUG|INFO|WARNING|ERROR|CRITICAL):([\w.]+):`, Groups: []string{"magenta", "blue+h"}, CaseSensitive: true},
+		{Pattern: ` - ([\w.]+) - (DEBUG|INFO|WARNING|ERROR|CRITICAL) - `, Groups: []string{"blue+h", "magenta"}, CaseSensitive: true},
+	}}),
+}
+
+// FormatTheme returns the highlighter for the log format, or nil if the format has no specific highlighter
+func FormatTheme(format string) *Theme {
+	return formatThemes[format]
+}
+
+func matchesAny(patterns []*regexp.Regexp, message string) bool {
+	for _, p := range patterns {
+		if p.MatchString(message) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClassifyMessage guesses the format of a single log message, returns FormatPlain if the format is not recognized
+func ClassifyMessage(message string) string {
+	trimmed := strings.TrimSpace(message)
+	_, structured := query.ParseFields(trimmed)
+	switch {
+	case structured == query.FormatJSON:
+		return FormatJSON
+	case accessLogPattern.MatchString(trimmed):
+		return FormatAccess
+	case structured == query.FormatLogfmt:
+		return FormatLogfmt
+	case matchesAny(javaPatterns, message):
+		return FormatJava
+	case matchesAny(pythonPatterns, message):
+		return FormatPython
+	}
+	return FormatPlain
+}
+
+type formatVotes struct {
+	votes   map[string]int
+	count   int
+	format  string
+	decided bool
+}
+
+// leader returns the format with the most votes, formats which are not recognized are only used if nothing
+// else was recognized, because lines in a stream with tracebacks or stack traces are mostly plain text
+func (v *formatVotes) leader() string {
+	best := FormatPlain
+	bestVotes := 0
+	for _, format := range Formats {
+		if format != FormatPlain && v.votes[format] > bestVotes {
+			best = format
+			bestVotes = v.votes[format]
+		}
+	}
+	return best
+}
+
+// FormatDetector detects the format of each log stream from its first events
+type FormatDetector struct {
+	format     string
+	sampleSize int
+	streams    map[string]*formatVotes
+}
+
+// NewFormatDetector creates a format detector. If format is FormatAuto then the format of each stream is detected
+// from its first sampleSize events, otherwise the format is used for all the streams
+func NewFormatDetector(format string, sampleSize int) (*FormatDetector, error) {
+	format = strings.ToLower(format)
+	if format == "" {
+		format = FormatAuto
+	}
+	valid := false
+	for _, f := range Formats {
+		valid = valid || f == format
+	}
+	if !valid {
+		return nil, fmt.Errorf("unknown log format '%s', expected one of %s", format, strings.Join(Formats, ", "))
+	}
+	return &FormatDetector{
+		format:     format,
+		sampleSize: sampleSize,
+		streams:    make(map[string]*formatVotes),
+	}, nil
+}
+
+// Format returns the format of the stream the message belongs to. While the stream is being sampled, the format
+// may change with every message, after sampleSize messages the format of the stream is fixed
+func (d *FormatDetector) Format(stream string, message string) string {
+	if d.format != FormatAuto {
+		return d.format
+	}
+	v, ok := d.streams[stream]
+	if !ok {
+		v = &formatVotes{votes: make(map[string]int)}
+		d.streams[stream] = v
+	}
+	if v.decided {
+		return v.format
+	}
+	v.votes[ClassifyMessage(message)]++
+	v.count++
+	v.format = v.leader()
+	v.decided = v.count >= d.sampleSize
+	return v.format
+}
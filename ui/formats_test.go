@@ -0,0 +1,94 @@
+package ui
+
+import (
+	"testing"
+)
+
+func TestClassifyMessage(t *testing.T) {
+	tests := []struct {
+		message  string
+		expected string
+	}{
+		{`{"level":"info","msg":"started","port":8080}`, FormatJSON},
+		{`{"level":"info"`, FormatPlain},
+		{`time=2026-10-19T10:00:00Z level=info msg="server started" port=8080`, FormatLogfmt},
+		{`10.0.0.1 - - [19/Oct/2026:10:00:00 +0000] "GET /health HTTP/1.1" 200 12 "-" "curl/8.0"`, FormatAccess},
+		{`10:00:00.123 [main] INFO  com.example.Application - Started`, FormatJava},
+		{"java.lang.IllegalStateException: boom\n\tat com.example.Service.run(Service.java:42)", FormatJava},
+		{"Traceback (most recent call last):\n  File \"app.py\", line 3, in <module>\nValueError: boom", FormatPython},
+		{`WARNING:app.db:slow query`, FormatPython},
+		{`2026-10-19 10:00:00,123 - app.db - ERROR - connection lost`, FormatPython},
+		{`Starting server on port 8080`, FormatPlain},
+		{`retries=3`, FormatPlain},
+	}
+	for _, test := range tests {
+		actual := ClassifyMessage(test.message)
+		if actual != test.expected {
+			t.Errorf("%q: expected %s, got %s", test.message, test.expected, actual)
+		}
+	}
+}
+
+func TestFormatDetectorSamplesEachStream(t *testing.T) {
+	detector, err := NewFormatDetector(FormatAuto, 3)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	steps := []struct {
+		stream   string
+		message  string
+		expected string
+	}{
+		{"a", "Starting", FormatPlain},
+		{"b", `{"msg":"one"}`, FormatJSON},
+		{"a", `level=info msg=ready`, FormatLogfmt},
+		{"a", `plain line`, FormatLogfmt},
+		{"b", `plain line`, FormatJSON},
+		// stream a is fixed after 3 samples
+		{"a", `{"msg":"two"}`, FormatLogfmt},
+		{"a", `{"msg":"three"}`, FormatLogfmt},
+	}
+	for i, step := range steps {
+		actual := detector.Format(step.stream, step.message)
+		if actual != step.expected {
+			t.Errorf("step %d: expected %s, got %s", i, step.expected, actual)
+		}
+	}
+}
+
+func TestFormatDetectorForcedFormat(t *testing.T) {
+	detector, err := NewFormatDetector("JSON", DefaultFormatSamples)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if actual := detector.Format("a", "plain line"); actual != FormatJSON {
+		t.Errorf("Expected json, got %s", actual)
+	}
+	if _, err = NewFormatDetector("xml", DefaultFormatSamples); err == nil {
+		t.Errorf("Expected error for unknown format")
+	}
+}
+
+func TestFormatThemes(t *testing.T) {
+	tests := []struct {
+		format   string
+		message  string
+		expected string
+	}{
+		{FormatJSON, `{"n":1,"ok":true}`,
+			"{\033[94m\"n\"\033[39m:\033[33m1\033[39m,\033[94m\"ok\"\033[39m:\033[35mtrue\033[39m}"},
+		{FormatLogfmt, `a=1 b="x y"`,
+			"\033[94ma\033[39m=\033[32m1\033[39m \033[94mb\033[39m=\033[32m\"x y\"\033[39m"},
+		{FormatAccess, `1.2.3.4 - bob [t] "GET /x HTTP/1.1" 503 0`,
+			"\033[36m1.2.3.4\033[39m - \033[34mbob\033[39m [\033[33mt\033[39m] \"\033[35;1mGET\033[39;22m \033[94m/x\033[39m HTTP/1.1\" \033[31;1m503\033[39;22m 0"},
+	}
+	for _, test := range tests {
+		actual := FormatTheme(test.format).Colorize(test.message)
+		if actual != test.expected {
+			t.Errorf("%s\nExpected: %q\n  Actual: %q", test.format, test.expected, actual)
+		}
+	}
+	if FormatTheme(FormatPlain) != nil {
+		t.Errorf("Expected no theme for plain format")
+	}
+}
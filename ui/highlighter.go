@@ -1,10 +1,7 @@
 package ui
 
 import (
-	"bytes"
-	"github.com/dlclark/regexp2"
 	"regexp"
-	"strings"
 )
 
 var (
@@ -17,125 +14,51 @@ var (
 		"red",
 	}
 
-	// errorBack = ColorFunc(":#FFD0D0")
-	errorBack = ColorWrapFunc(":red")
+	// errorBack = ":#FFD0D0"
+	errorBack = ":red"
 
-	warnBack = ColorWrapFunc(":yellow")
+	warnBack = ":yellow"
+
+	//StreamNameStyle is a style for log stream name
+	StreamNameStyle = "+b"
+	//TimestampStyle is a style for log event timestamp
+	TimestampStyle = "+i"
 
 	//StreamNameColorizer is a colorizer function for log stream name
-	StreamNameColorizer = ColorWrapFunc("+b")
+	StreamNameColorizer = ColorWrapFunc(StreamNameStyle)
 	//TimestampColorizer is a colorizer function for log event timestamp
-	TimestampColorizer = ColorWrapFunc("+i")
-
-	colorFuncs = createColorFuncs()
-
-	standardColorsRe = regexp2.MustCompile(`(?<darkgoldenrod>\d{2}(?:[-:.]\d{2,3}){1,3})|`+
-		`(?:\[(?<darkcyan>[^]]+)])|`+
-		`(?:\s+(?<RoyalBlue>(?:"[^"]+")|(?:'[^']+'))\s+)|`+
-		`(?:\b(?<plum>info|error|warn|trace|debug|warning)\b)|`+
-		`(?:\b(?<bisque>\d[\d.]+)\b)|`+
-		`(?:(?<turquoise>[\p{L}\d._]+)(?:=|:)(?<lightseagreen>['"]?[\p{L}\d._]+)['"]?)`, // key/value
-		regexp2.IgnoreCase)
+	TimestampColorizer = ColorWrapFunc(TimestampStyle)
 )
 
-func createColorFuncs() []ColorizerFunc {
-	result := make([]ColorizerFunc, len(colors))
-	for i, color := range colors {
-		result[i] = ColorWrapFunc(color)
-	}
-	return result
-}
-
-// HighlightLogLevel applies loglevel-specific background color to the provided text
-// Warning background is applied if logLevel is equal to "WARN" or "WARNING" and
-// Error background is applied for "ERROR" logLevel
-func HighlightLogLevel(detectedLevels []string, matches []string, text string) string {
-	for i, level := range detectedLevels {
-		if matches[i] != "" {
-			switch level {
-			case "error":
-				return errorBack(text)
-			case "warning":
-				return warnBack(text)
+// ColorizeText adds a distinct color to each regex group for every match of the pattern in each line of the text
+func ColorizeText(pattern *regexp.Regexp, text *StyledText, priority int) {
+	for _, r := range LineRanges(text.Text()) {
+		line := text.Text()[r[0]:r[1]]
+		for _, grpIndices := range pattern.FindAllStringSubmatchIndex(line, -1) {
+			colorIndex := 0
+			for groupPosIndex := 2; groupPosIndex < len(grpIndices); groupPosIndex += 2 {
+				if grpIndices[groupPosIndex] >= 0 {
+					text.AddStyle(r[0]+grpIndices[groupPosIndex], r[0]+grpIndices[groupPosIndex+1], colors[colorIndex], priority)
+				}
+				colorIndex = (colorIndex + 1) % len(colors)
 			}
 		}
 	}
-	return text
 }
 
 // Colorize adds ansi color tags to each regex groups if the pattern matches
 func Colorize(pattern *regexp.Regexp, text string) string {
-	grpIndices := pattern.FindStringSubmatchIndex(text)
-	if grpIndices == nil {
-		return text
-	}
-	var sb strings.Builder
-	colorIndex := 0
-	groupPosIndex := 2
-	pos := 0
-	for groupPosIndex < len(grpIndices) {
-		beforeGroup := text[pos:grpIndices[groupPosIndex]]
-		pos = grpIndices[groupPosIndex+1]
-		sb.WriteString(beforeGroup)
-		sb.WriteString(colorFuncs[colorIndex](text[grpIndices[groupPosIndex]:grpIndices[groupPosIndex+1]]))
-		groupPosIndex += 2
-		colorIndex++
-		if colorIndex >= len(colorFuncs) {
-			colorIndex = 0
-		}
-	}
-	lastGroup := text[pos:]
-	sb.WriteString(lastGroup)
-
-	return sb.String()
+	styled := NewStyledText(text)
+	ColorizeText(pattern, styled, PriorityTheme)
+	return styled.Render()
 }
 
 // HighlightSelection highlights slice of the string with indexes provided in selection with a style
 func HighlightSelection(text string, selection []int, style string) string {
-	buf := bytes.NewBufferString("")
-	buf.WriteString(text[:selection[0]])
-	wrap := ColorWrap(text[selection[0]:selection[1]], style)
-	buf.WriteString(wrap)
-	buf.WriteString(text[selection[1]:])
-	s := buf.String()
-	return s
+	return NewStyledText(text).AddStyle(selection[0], selection[1], style, PriorityFilter).Render()
 }
 
+// ColorizeByColorName highlights parts of the text using the default theme
 func ColorizeByColorName(text string) string {
-	matcher, err := standardColorsRe.FindStringMatch(text)
-	if err != nil {
-		panic(err)
-	}
-	pos := 0
-	var sb strings.Builder
-
-	for matcher != nil && len(matcher.Groups()) > 1 {
-		for _, group := range matcher.Groups()[1:] {
-			if len(group.Captures) > 0 {
-				capt := group.Capture
-				beforeGroup := text[pos:capt.Index]
-				pos = capt.Index + capt.Length
-				sb.WriteString(beforeGroup)
-
-				colorName := group.Name
-				colorId := -1
-				if colorName != "" {
-					colorId = NameToAnsi256(colorName)
-				}
-				if colorId >= 0 {
-					sb.WriteString(Color256Wrap(capt.String(), colorName))
-				} else {
-					sb.WriteString(capt.String())
-				}
-			}
-		}
-		matcher, err = standardColorsRe.FindNextMatch(matcher)
-		if err != nil {
-			panic(err)
-		}
-	}
-	lastGroup := text[pos:]
-	sb.WriteString(lastGroup)
-
-	return sb.String()
+	return DarkTheme.Colorize(text)
 }
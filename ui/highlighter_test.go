@@ -16,7 +16,8 @@ func TestHighlightSelection(t *testing.T) {
 func TestColorizeByColorName(t *testing.T) {
 	log := "[test1] [test2] 11:12"
 	actual := ColorizeByColorName(log)
-	expected := "[\033[38;5;36mtest1\033[39;49m] [\033[38;5;36mtest2\033[39;49m] \033[38;5;136m11:12\033[39;49m"
+	// theme styles set only foreground colors, so only the foreground is reset after each match
+	expected := "[\033[38;5;36mtest1\033[39m] [\033[38;5;36mtest2\033[39m] \033[38;5;136m11:12\033[39m"
 
 	if actual != expected {
 		t.Errorf("\nExpected: '%v'\n  Actual: '%v'", expected, actual)
@@ -0,0 +1,207 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/uaraven/cwltail/query"
+)
+
+// Styles of the rendered JSON messages
+var (
+	JSONKeyStyle     = "blue+h"
+	JSONStringStyle  = "green"
+	JSONNumberStyle  = "yellow"
+	JSONLiteralStyle = "magenta"
+	JSONBracketStyle = "cyan+b"
+)
+
+const jsonTimeFormat = "2006-01-02T15:04:05.000Z07:00"
+
+var jsonTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02 15:04:05,999",
+}
+
+// ParseJSONPath splits a dot-separated path, such as "log.level", into field names
+func ParseJSONPath(path string) []string {
+	return strings.Split(path, ".")
+}
+
+// JSONTimestamp converts a JSON value into time. Strings are parsed as RFC3339 or similar formats,
+// numbers are epoch seconds, milliseconds, microseconds or nanoseconds, depending on their magnitude
+func JSONTimestamp(value interface{}) (time.Time, bool) {
+	switch v := value.(type) {
+	case string:
+		for _, layout := range jsonTimeLayouts {
+			if tm, err := time.Parse(layout, v); err == nil {
+				return tm, true
+			}
+		}
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil || f <= 0 {
+			break
+		}
+		switch {
+		case f >= 1e17:
+			return time.Unix(0, int64(f)), true
+		case f >= 1e14:
+			return time.Unix(0, int64(f*1e3)), true
+		case f >= 1e11:
+			return time.Unix(0, int64(f*1e6)), true
+		default:
+			sec, frac := math.Modf(f)
+			return time.Unix(int64(sec), int64(frac*1e9)), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// JSONRenderer renders JSON messages as selected field values followed by the rest of the fields
+// as key=value pairs
+type JSONRenderer struct {
+	fields [][]string
+	pretty bool
+}
+
+// NewJSONRenderer creates a renderer which displays values of the fields first. If pretty is true then the rest
+// of the fields are displayed one per line, with nested objects indented, otherwise they are displayed in one line
+func NewJSONRenderer(fields []string, pretty bool) *JSONRenderer {
+	r := &JSONRenderer{pretty: pretty}
+	for _, field := range fields {
+		if field = strings.TrimSpace(field); field != "" {
+			r.fields = append(r.fields, ParseJSONPath(field))
+		}
+	}
+	return r
+}
+
+// Render renders the parsed JSON message into the styled text
+func (r *JSONRenderer) Render(obj *query.Object) *StyledText {
+	text := NewStyledText("")
+	shown := make(map[string]bool)
+	for _, path := range r.fields {
+		value, ok := obj.Lookup(path)
+		if !ok {
+			continue
+		}
+		if len(path) == 1 {
+			shown[path[0]] = true
+		}
+		if text.Len() > 0 {
+			text.Append(" ", "")
+		}
+		if s, isString := value.(string); isString {
+			text.Append(s, "")
+		} else {
+			r.renderValue(text, value, 0)
+		}
+	}
+	for _, key := range obj.Keys {
+		if shown[key] {
+			continue
+		}
+		if r.pretty {
+			if text.Len() > 0 {
+				text.Append("\n", "")
+			}
+			text.Append("  ", "")
+		} else if text.Len() > 0 {
+			text.Append(" ", "")
+		}
+		r.renderField(text, key, obj.Values[key], 1)
+	}
+	return text
+}
+
+func (r *JSONRenderer) renderField(text *StyledText, key string, value interface{}, depth int) {
+	appendStyled(text, key, JSONKeyStyle)
+	text.Append("=", "")
+	r.renderValue(text, value, depth)
+}
+
+func (r *JSONRenderer) renderValue(text *StyledText, value interface{}, depth int) {
+	switch v := value.(type) {
+	case *query.Object:
+		appendStyled(text, "{", JSONBracketStyle)
+		for i, key := range v.Keys {
+			if r.pretty {
+				text.Append("\n"+strings.Repeat("  ", depth+1), "")
+			} else if i > 0 {
+				text.Append(" ", "")
+			}
+			r.renderField(text, key, v.Values[key], depth+1)
+		}
+		if r.pretty && len(v.Keys) > 0 {
+			text.Append("\n"+strings.Repeat("  ", depth), "")
+		}
+		appendStyled(text, "}", JSONBracketStyle)
+	case []interface{}:
+		appendStyled(text, "[", JSONBracketStyle)
+		for i, item := range v {
+			if i > 0 {
+				text.Append(" ", "")
+			}
+			r.renderValue(text, item, depth)
+		}
+		appendStyled(text, "]", JSONBracketStyle)
+	case string:
+		appendStyled(text, quoteJSONString(v), JSONStringStyle)
+	case json.Number:
+		appendStyled(text, v.String(), JSONNumberStyle)
+	case bool:
+		appendStyled(text, strconv.FormatBool(v), JSONLiteralStyle)
+	case nil:
+		appendStyled(text, "null", JSONLiteralStyle)
+	case time.Time:
+		appendStyled(text, v.Format(jsonTimeFormat), TimestampStyle)
+	default:
+		text.Append(fmt.Sprint(v), "")
+	}
+}
+
+// RenderColumns renders values, such as the result of the selection, separated by spaces.
+// Strings are displayed as is, other values are displayed the same way as fields of JSON messages
+func RenderColumns(values []interface{}) *StyledText {
+	r := &JSONRenderer{}
+	text := NewStyledText("")
+	for i, value := range values {
+		if i > 0 {
+			text.Append(" ", "")
+		}
+		if s, isString := value.(string); isString {
+			text.Append(s, "")
+		} else {
+			r.renderValue(text, value, 0)
+		}
+	}
+	return text
+}
+
+// appendStyled appends the text with theme priority, so highlights and filter matches are displayed over it
+func appendStyled(text *StyledText, s string, style string) {
+	start := text.Len()
+	text.Append(s, "")
+	text.AddStyle(start, text.Len(), style, PriorityTheme)
+}
+
+// quoteJSONString quotes the string if it would be ambiguous in key=value output
+func quoteJSONString(s string) string {
+	if s == "" || strings.ContainsAny(s, " \t\n\"=") {
+		var buf bytes.Buffer
+		encoder := json.NewEncoder(&buf)
+		encoder.SetEscapeHTML(false)
+		_ = encoder.Encode(s)
+		return strings.TrimSuffix(buf.String(), "\n")
+	}
+	return s
+}
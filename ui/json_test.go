@@ -0,0 +1,83 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/uaraven/cwltail/query"
+)
+
+func TestJSONTimestamp(t *testing.T) {
+	expected := time.Date(2026, 10, 19, 10, 0, 0, 500000000, time.UTC)
+	tests := []struct {
+		message string
+		ok      bool
+	}{
+		{`{"t":"2026-10-19T10:00:00.5Z"}`, true},
+		{`{"t":"2026-10-19 10:00:00,500"}`, true},
+		{`{"t":1792404000.5}`, true},
+		{`{"t":1792404000500}`, true},
+		{`{"t":1792404000500000}`, true},
+		{`{"t":1792404000500000000}`, true},
+		{`{"t":"yesterday"}`, false},
+		{`{"t":true}`, false},
+	}
+	for _, test := range tests {
+		obj, _ := query.ParseJSON(test.message)
+		value, _ := obj.Lookup([]string{"t"})
+		tm, ok := JSONTimestamp(value)
+		if ok != test.ok || (ok && !tm.Equal(expected)) {
+			t.Errorf("%s: expected %v %v, got %v %v", test.message, expected, test.ok, tm, ok)
+		}
+	}
+}
+
+func TestJSONRenderer(t *testing.T) {
+	message := `{"time":"10:00","level":"info","msg":"request done","http":{"method":"GET","status":200},"tags":["a","b c"],"ok":true,"err":null}`
+	tests := []struct {
+		fields   []string
+		pretty   bool
+		expected string
+	}{
+		{[]string{"time", "level", "msg", "missing"}, false,
+			`10:00 info request done http={method=GET status=200} tags=[a "b c"] ok=true err=null`},
+		{[]string{"msg", "http.status"}, false,
+			`request done 200 time=10:00 level=info http={method=GET status=200} tags=[a "b c"] ok=true err=null`},
+		{[]string{"msg"}, true,
+			"request done\n  time=10:00\n  level=info\n  http={\n    method=GET\n    status=200\n  }\n  tags=[a \"b c\"]\n  ok=true\n  err=null"},
+		{nil, false,
+			`time=10:00 level=info msg="request done" http={method=GET status=200} tags=[a "b c"] ok=true err=null`},
+	}
+	obj, _ := query.ParseJSON(message)
+	for _, test := range tests {
+		actual := NewJSONRenderer(test.fields, test.pretty).Render(obj).Text()
+		if actual != test.expected {
+			t.Errorf("%v %v\nExpected: %q\n  Actual: %q", test.fields, test.pretty, test.expected, actual)
+		}
+	}
+}
+
+func TestJSONRendererStyles(t *testing.T) {
+	obj, _ := query.ParseJSON(`{"msg":"hi","n":1,"o":{"b":false}}`)
+	actual := NewJSONRenderer([]string{"msg"}, false).Render(obj).Render()
+	expected := "hi \033[94mn\033[39m=\033[33m1\033[39m \033[94mo\033[39m=\033[36;1m{\033[94;22mb\033[39m=\033[35mfalse\033[36;1m}\033[39;22m"
+	if actual != expected {
+		t.Errorf("\nExpected: %q\n  Actual: %q", expected, actual)
+	}
+}
+
+func TestRenderColumns(t *testing.T) {
+	obj, _ := query.ParseJSON(`{"n":503,"o":{"b":"x y","a":true}}`)
+	values := []interface{}{
+		"/api",
+		obj.Values["n"],
+		nil,
+		obj.Values["o"],
+		time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC),
+	}
+	actual := RenderColumns(values).Text()
+	expected := `/api 503 null {b="x y" a=true} 2026-10-19T10:00:00.000Z`
+	if actual != expected {
+		t.Errorf("\nExpected: %q\n  Actual: %q", expected, actual)
+	}
+}
@@ -0,0 +1,72 @@
+package ui
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// GutterStyle is the style of the gutter in front of continuation lines of multi-line messages
+var GutterStyle = "+d"
+
+// NoteStyle is the style of notes added to messages, such as the number of folded stack trace lines
+var NoteStyle = "+d"
+
+// EscapedNewline is displayed instead of newlines when messages are displayed in one line
+const EscapedNewline = `\n`
+
+// gutter returns the gutter for continuation lines, which is as wide as the prefix of the first line
+func gutter(width int) string {
+	if width < 2 {
+		return "  "
+	}
+	return strings.Repeat(" ", width-2) + "│ "
+}
+
+// PrefixLines adds the prefix, such as stream name and timestamp, in front of the first line of the message,
+// and a gutter in front of each continuation line, so all the lines of the message are aligned
+func PrefixLines(prefix *StyledText, message *StyledText) *StyledText {
+	g := gutter(utf8.RuneCountInString(prefix.Text()))
+	lines := message.Lines()
+	result := prefix.AppendText(lines[0])
+	for _, line := range lines[1:] {
+		result.Append("\n", "").Append(g, GutterStyle).AppendText(line)
+	}
+	return result
+}
+
+// Line is the message of an event waiting to be displayed. The prefix with stream name and time of the event
+// is added when the line is printed, because relative time depends on the line printed before it
+type Line struct {
+	Stream    string
+	Timestamp time.Time
+	Message   *StyledText
+	// Context is true for lines displayed around matching lines, they are dimmed
+	Context bool
+	// Separator is true for lines separating groups of other lines, they are displayed without prefix
+	Separator bool
+}
+
+// Styled returns the message with the prefix in front of it, see PrefixLines
+func (l *Line) Styled(prefix *StyledText) *StyledText {
+	if l.Separator {
+		return l.Message
+	}
+	text := PrefixLines(prefix, l.Message)
+	if l.Context {
+		text.AddStyle(0, text.Len(), ContextStyle, PriorityLine)
+	}
+	return text
+}
+
+// SeparatorStyle is the style of separator lines, such as idle gap markers
+var SeparatorStyle = "+d"
+
+// Separator creates a line of the width with the label, e.g. "── 2m30s without events ─────"
+func Separator(label string, width int) *StyledText {
+	text := "── " + label + " "
+	if rest := width - utf8.RuneCountInString(text); rest > 0 {
+		text += strings.Repeat("─", rest)
+	}
+	return NewStyledText("").Append(text, SeparatorStyle)
+}
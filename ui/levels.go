@@ -0,0 +1,94 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Ways to highlight a log line according to its level
+const (
+	// LevelModeLine applies the style to the whole line
+	LevelModeLine = "line"
+	// LevelModeForeground applies only foreground color and attributes of the style to the whole line
+	LevelModeForeground = "fg"
+	// LevelModeToken applies the style only to the level token in the line
+	LevelModeToken = "token"
+	// LevelModeNone disables highlighting for the level
+	LevelModeNone = "none"
+)
+
+// LevelStyle defines how lines of the certain log level are highlighted
+type LevelStyle struct {
+	Mode  string
+	Style string
+}
+
+// LevelStyles maps level names (trace, debug, info, warning, error and fatal) to their styles
+type LevelStyles map[string]LevelStyle
+
+// DefaultLevelStyles returns default styles for all the levels
+func DefaultLevelStyles() LevelStyles {
+	return LevelStyles{
+		"trace":   {Mode: LevelModeForeground, Style: "grey42"},
+		"debug":   {Mode: LevelModeForeground, Style: "grey62"},
+		"info":    {Mode: LevelModeNone},
+		"warning": {Mode: LevelModeLine, Style: warnBack},
+		"error":   {Mode: LevelModeLine, Style: errorBack},
+		"fatal":   {Mode: LevelModeLine, Style: "white+b:darkred"},
+	}
+}
+
+// ParseLevelStyle parses level style in LEVEL=MODE[:STYLE] format, e.g. "error=line::red",
+// "warning=token:black:yellow" or "debug=fg:grey50", and returns the level name and its style
+func ParseLevelStyle(spec string) (string, LevelStyle, error) {
+	parts := strings.SplitN(spec, "=", 2)
+	if len(parts) != 2 {
+		return "", LevelStyle{}, fmt.Errorf("invalid level style '%s', expected LEVEL=MODE[:STYLE]", spec)
+	}
+	level := strings.ToLower(strings.TrimSpace(parts[0]))
+	if level == "warn" {
+		level = "warning"
+	}
+	if _, ok := DefaultLevelStyles()[level]; !ok {
+		return "", LevelStyle{}, fmt.Errorf("unknown log level '%s' in '%s'", parts[0], spec)
+	}
+	modeStyle := strings.SplitN(parts[1], ":", 2)
+	style := LevelStyle{Mode: strings.ToLower(modeStyle[0])}
+	if len(modeStyle) > 1 {
+		style.Style = modeStyle[1]
+	}
+	switch style.Mode {
+	case LevelModeLine, LevelModeForeground, LevelModeToken:
+		if style.Style == "" {
+			return "", LevelStyle{}, fmt.Errorf("style is required for '%s' mode in '%s'", style.Mode, spec)
+		}
+	case LevelModeNone:
+	default:
+		return "", LevelStyle{}, fmt.Errorf("unknown mode '%s' in '%s', expected line, fg, token or none", style.Mode, spec)
+	}
+	if err := ValidateStyle(style.Style); err != nil {
+		return "", LevelStyle{}, fmt.Errorf("%v in '%s'", err, spec)
+	}
+	return level, style, nil
+}
+
+// Apply highlights the text according to the style of the level. token contains start and end of the
+// level token in the text and is used in token mode
+func (ls LevelStyles) Apply(text *StyledText, level string, token []int) {
+	style, ok := ls[level]
+	if !ok {
+		return
+	}
+	switch style.Mode {
+	case LevelModeLine:
+		text.AddStyle(0, text.Len(), style.Style, PriorityLine)
+	case LevelModeForeground:
+		fg := ParseStyle(style.Style)
+		fg.Bg = ""
+		text.AddSpan(0, text.Len(), fg, PriorityLine)
+	case LevelModeToken:
+		if token != nil {
+			text.AddStyle(token[0], token[1], style.Style, PriorityHighlight)
+		}
+	}
+}
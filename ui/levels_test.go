@@ -0,0 +1,63 @@
+package ui
+
+import (
+	"testing"
+)
+
+func TestParseLevelStyle(t *testing.T) {
+	tests := []struct {
+		spec     string
+		level    string
+		expected LevelStyle
+	}{
+		{"error=line::red", "error", LevelStyle{Mode: LevelModeLine, Style: ":red"}},
+		{"WARN=token:black:yellow", "warning", LevelStyle{Mode: LevelModeToken, Style: "black:yellow"}},
+		{"debug=fg:grey50", "debug", LevelStyle{Mode: LevelModeForeground, Style: "grey50"}},
+		{"info=none", "info", LevelStyle{Mode: LevelModeNone}},
+	}
+	for _, test := range tests {
+		level, style, err := ParseLevelStyle(test.spec)
+		if err != nil {
+			t.Errorf("'%s': unexpected error %v", test.spec, err)
+			continue
+		}
+		if level != test.level || style != test.expected {
+			t.Errorf("'%s'\nExpected: %s %+v\n  Actual: %s %+v", test.spec, test.level, test.expected, level, style)
+		}
+	}
+}
+
+func TestParseLevelStyleErrors(t *testing.T) {
+	for _, spec := range []string{"error", "verbose=line:red", "error=blink:red", "error=line", "error=line:nocolor"} {
+		if _, _, err := ParseLevelStyle(spec); err == nil {
+			t.Errorf("'%s': expected error", spec)
+		}
+	}
+}
+
+func TestLevelStyleModes(t *testing.T) {
+	styles := LevelStyles{
+		"error":   {Mode: LevelModeLine, Style: ":red"},
+		"warning": {Mode: LevelModeToken, Style: "black:yellow"},
+		"debug":   {Mode: LevelModeForeground, Style: "grey50:blue"},
+		"info":    {Mode: LevelModeNone},
+	}
+	tests := []struct {
+		level    string
+		expected string
+	}{
+		{"error", "\033[41mERROR failed\033[49m"},
+		{"warning", "\033[30;43mERROR\033[39;49m failed"},
+		{"debug", "\033[38;5;244mERROR failed\033[39m"},
+		{"info", "ERROR failed"},
+		{"trace", "ERROR failed"},
+	}
+	for _, test := range tests {
+		text := NewStyledText("ERROR failed")
+		styles.Apply(text, test.level, []int{0, 5})
+		actual := text.Render()
+		if actual != test.expected {
+			t.Errorf("%s\nExpected: %q\n  Actual: %q", test.level, test.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package ui
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Styles of the pattern table
+var (
+	PatternHeaderStyle      = "+b"
+	PatternCountStyle       = "yellow"
+	PatternPlaceholderStyle = "cyan"
+	PatternSampleStyle      = "+d"
+)
+
+// PatternRow is a message template with its statistics, displayed in the pattern table
+type PatternRow struct {
+	Template string
+	Count    int
+	// Rate is the number of messages per second
+	Rate   float64
+	Sample string
+}
+
+var placeholder = regexp.MustCompile(`<\w+>`)
+
+const (
+	patternColumns = "  COUNT   RATE/s  "
+	patternIndent  = "                  "
+)
+
+// truncate cuts the text to the width in runes, replacing the last visible rune with an ellipsis.
+// Newlines are escaped, so each row takes one line
+func truncate(text string, width int) string {
+	text = strings.ReplaceAll(text, "\n", EscapedNewline)
+	if width <= 0 {
+		return ""
+	}
+	if utf8.RuneCountInString(text) <= width {
+		return text
+	}
+	return string([]rune(text)[:width-1]) + "…"
+}
+
+// RenderPatterns renders the table of templates, each followed by its sample message, fitting into the width.
+// title is displayed in the header line
+func RenderPatterns(title string, rows []PatternRow, width int) []*StyledText {
+	header := NewStyledText("")
+	header.Append(truncate(title, width), PatternHeaderStyle)
+	columns := NewStyledText("")
+	columns.Append(truncate(patternColumns+"PATTERN", width), PatternHeaderStyle)
+	lines := []*StyledText{header, columns}
+	textWidth := width - len(patternIndent)
+	for _, row := range rows {
+		line := NewStyledText("")
+		line.Append(fmt.Sprintf("%7d ", row.Count), PatternCountStyle)
+		line.Append(fmt.Sprintf("%8.2f  ", row.Rate), "")
+		start := line.Len()
+		line.Append(truncate(row.Template, textWidth), "")
+		for _, m := range placeholder.FindAllStringIndex(line.Text()[start:], -1) {
+			line.AddStyle(start+m[0], start+m[1], PatternPlaceholderStyle, PriorityTheme)
+		}
+		lines = append(lines, line)
+		sample := NewStyledText(patternIndent)
+		sample.Append(truncate(row.Sample, textWidth), PatternSampleStyle)
+		lines = append(lines, sample)
+	}
+	return lines
+}
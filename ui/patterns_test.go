@@ -0,0 +1,38 @@
+package ui
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRenderPatterns(t *testing.T) {
+	rows := []PatternRow{
+		{Template: "request <num> done in <num>ms", Count: 1234, Rate: 2.5, Sample: "request 42 done in 17ms"},
+		{Template: "failed:\nretrying", Count: 3, Rate: 0, Sample: "failed:\nretrying"},
+	}
+	lines := RenderPatterns("group: 1237 events", rows, 40)
+	var texts []string
+	for _, line := range lines {
+		texts = append(texts, line.Text())
+	}
+	expected := []string{
+		"group: 1237 events",
+		"  COUNT   RATE/s  PATTERN",
+		"   1234     2.50  request <num> done in…",
+		"                  request 42 done in 17…",
+		"      3     0.00  failed:\\nretrying",
+		"                  failed:\\nretrying",
+	}
+	if !reflect.DeepEqual(texts, expected) {
+		t.Errorf("\nExpected: %q\n  Actual: %q", expected, texts)
+	}
+	var placeholders []string
+	for _, s := range lines[2].spans {
+		if s.priority == PriorityTheme {
+			placeholders = append(placeholders, lines[2].Text()[s.start:s.end])
+		}
+	}
+	if !reflect.DeepEqual(placeholders, []string{"<num>"}) {
+		t.Errorf("Expected highlighted placeholder, got %q", placeholders)
+	}
+}
@@ -0,0 +1,275 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/term"
+)
+
+const (
+	keyCtrlC     = 3
+	keyTab       = 9
+	keyEnter     = 13
+	keyCtrlN     = 14
+	keyCtrlP     = 16
+	keyCtrlU     = 21
+	keyEscape    = 27
+	keyBackspace = 127
+
+	pickerVisibleItems = 20
+)
+
+// ErrPickerCancelled is returned by Pick if user cancelled the selection
+var ErrPickerCancelled = errors.New("selection cancelled")
+
+var (
+	pickerMatchColorizer    = ColorWrapFunc("cyan+b")
+	pickerCursorColorizer   = ColorWrapFunc(":grey23")
+	pickerSelectedColorizer = ColorWrapFunc("green+b")
+	pickerPromptColorizer   = ColorWrapFunc("yellow+b")
+)
+
+// FuzzyMatch checks if all characters of the pattern are present in the text in the same order, ignoring case.
+// It returns the score of the match, higher score means better match, and positions of the matched
+// characters in the text. If the text doesn't match the pattern, positions are nil
+func FuzzyMatch(pattern string, text string) (int, []int) {
+	runes := []rune(strings.ToLower(text))
+	patternRunes := []rune(strings.ToLower(pattern))
+	if len(patternRunes) == 0 {
+		return 0, []int{}
+	}
+	bestScore := -1
+	var bestPositions []int
+	// try every occurrence of the first pattern character as a starting point and keep the best match
+	for start, r := range runes {
+		if r != patternRunes[0] {
+			continue
+		}
+		score, positions := fuzzyMatchFrom(patternRunes, runes, start)
+		if positions != nil && score > bestScore {
+			bestScore = score
+			bestPositions = positions
+		}
+	}
+	if bestPositions == nil {
+		return 0, nil
+	}
+	return bestScore, bestPositions
+}
+
+func fuzzyMatchFrom(pattern []rune, text []rune, start int) (int, []int) {
+	positions := make([]int, 0, len(pattern))
+	score := 0
+	ti := start
+	prev := -2
+	for _, pc := range pattern {
+		for ti < len(text) && text[ti] != pc {
+			ti++
+		}
+		if ti >= len(text) {
+			return 0, nil
+		}
+		score++
+		if ti == prev+1 {
+			// consecutive characters match better
+			score += 5
+		}
+		if ti == 0 || strings.ContainsRune("/-_. :", text[ti-1]) {
+			// start of a word matches better
+			score += 3
+		}
+		positions = append(positions, ti)
+		prev = ti
+		ti++
+	}
+	return score, positions
+}
+
+type pickerItem struct {
+	index     int
+	score     int
+	positions []int
+}
+
+type picker struct {
+	title    string
+	items    []string
+	multi    bool
+	query    []rune
+	matches  []pickerItem
+	cursor   int
+	offset   int
+	selected map[int]bool
+}
+
+func (p *picker) filter() {
+	p.matches = p.matches[:0]
+	query := string(p.query)
+	for i, item := range p.items {
+		score, positions := FuzzyMatch(query, item)
+		if positions != nil {
+			p.matches = append(p.matches, pickerItem{index: i, score: score, positions: positions})
+		}
+	}
+	sort.SliceStable(p.matches, func(i, j int) bool {
+		return p.matches[i].score > p.matches[j].score
+	})
+	p.cursor = 0
+	p.offset = 0
+}
+
+func (p *picker) moveCursor(delta int) {
+	p.cursor += delta
+	if p.cursor < 0 {
+		p.cursor = 0
+	}
+	if p.cursor >= len(p.matches) {
+		p.cursor = len(p.matches) - 1
+	}
+	if p.cursor < p.offset {
+		p.offset = p.cursor
+	}
+	if p.cursor >= p.offset+pickerVisibleItems {
+		p.offset = p.cursor - pickerVisibleItems + 1
+	}
+}
+
+func highlightPositions(text string, positions []int) string {
+	var sb strings.Builder
+	pi := 0
+	for i, r := range []rune(text) {
+		if pi < len(positions) && positions[pi] == i {
+			sb.WriteString(pickerMatchColorizer(string(r)))
+			pi++
+		} else {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+func (p *picker) render() string {
+	var sb strings.Builder
+	sb.WriteString("\033[H\033[2J")
+	help := "Enter to accept, Esc to cancel"
+	if p.multi {
+		help = "Tab to select, " + help
+	}
+	sb.WriteString(fmt.Sprintf("%s (%d/%d) %s\r\n", p.title, len(p.matches), len(p.items), help))
+	sb.WriteString(pickerPromptColorizer("> ") + string(p.query) + "\r\n")
+	for i := p.offset; i < len(p.matches) && i < p.offset+pickerVisibleItems; i++ {
+		match := p.matches[i]
+		mark := "  "
+		if p.selected[match.index] {
+			mark = pickerSelectedColorizer("* ")
+		}
+		line := highlightPositions(p.items[match.index], match.positions)
+		if i == p.cursor {
+			line = pickerCursorColorizer(line)
+		}
+		sb.WriteString(mark + line + "\r\n")
+	}
+	return sb.String()
+}
+
+func (p *picker) result() []string {
+	result := make([]string, 0)
+	for i, item := range p.items {
+		if p.selected[i] {
+			result = append(result, item)
+		}
+	}
+	if len(result) == 0 && len(p.matches) > 0 {
+		result = append(result, p.items[p.matches[p.cursor].index])
+	}
+	return result
+}
+
+// Pick displays interactive fuzzy finder over items and returns items selected by user.
+// If multi is true then user can select more than one item. Picker is displayed on stderr and
+// requires stdin to be a terminal
+func Pick(title string, items []string, multi bool) ([]string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return nil, errors.New("interactive selection requires a terminal")
+	}
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return nil, err
+	}
+	// use alternate screen buffer, so the picker doesn't leave anything behind
+	fmt.Fprint(os.Stderr, "\033[?1049h")
+	defer func() {
+		fmt.Fprint(os.Stderr, "\033[?1049l")
+		term.Restore(fd, state)
+	}()
+
+	p := &picker{
+		title:    title,
+		items:    items,
+		multi:    multi,
+		selected: make(map[int]bool),
+	}
+	p.filter()
+
+	buf := make([]byte, 64)
+	for {
+		fmt.Fprint(os.Stderr, p.render())
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		key := buf[:n]
+		switch {
+		case key[0] == keyCtrlC || (n == 1 && key[0] == keyEscape):
+			return nil, ErrPickerCancelled
+		case key[0] == keyEnter:
+			result := p.result()
+			if len(result) == 0 {
+				continue
+			}
+			return result, nil
+		case n >= 3 && key[0] == keyEscape && key[1] == '[':
+			switch key[2] {
+			case 'A':
+				p.moveCursor(-1)
+			case 'B':
+				p.moveCursor(1)
+			case '5':
+				p.moveCursor(-pickerVisibleItems)
+			case '6':
+				p.moveCursor(pickerVisibleItems)
+			}
+		case key[0] == keyCtrlP:
+			p.moveCursor(-1)
+		case key[0] == keyCtrlN:
+			p.moveCursor(1)
+		case key[0] == keyTab:
+			if p.multi && len(p.matches) > 0 {
+				index := p.matches[p.cursor].index
+				p.selected[index] = !p.selected[index]
+				p.moveCursor(1)
+			}
+		case key[0] == keyBackspace || key[0] == '\b':
+			if len(p.query) > 0 {
+				p.query = p.query[:len(p.query)-1]
+				p.filter()
+			}
+		case key[0] == keyCtrlU:
+			p.query = p.query[:0]
+			p.filter()
+		default:
+			for _, r := range string(key) {
+				if unicode.IsPrint(r) {
+					p.query = append(p.query, r)
+				}
+			}
+			p.filter()
+		}
+	}
+}
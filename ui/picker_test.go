@@ -0,0 +1,36 @@
+package ui
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFuzzyMatchPositions(t *testing.T) {
+	_, positions := FuzzyMatch("apf", "/aws/lambda/payment-function")
+	expected := []int{1, 12, 20}
+	if !reflect.DeepEqual(positions, expected) {
+		t.Errorf("\nExpected: %v\n  Actual: %v", expected, positions)
+	}
+}
+
+func TestFuzzyMatchNoMatch(t *testing.T) {
+	_, positions := FuzzyMatch("xyz", "/aws/lambda/payment-function")
+	if positions != nil {
+		t.Errorf("Expected no match, got %v", positions)
+	}
+}
+
+func TestFuzzyMatchIgnoresCase(t *testing.T) {
+	_, positions := FuzzyMatch("LAMBDA", "/aws/lambda/api")
+	if positions == nil {
+		t.Errorf("Expected match")
+	}
+}
+
+func TestFuzzyMatchPrefersConsecutive(t *testing.T) {
+	consecutive, _ := FuzzyMatch("api", "/aws/lambda/api")
+	scattered, _ := FuzzyMatch("api", "/aws/lambda/payment-invoice")
+	if consecutive <= scattered {
+		t.Errorf("Expected consecutive match score %d to be higher than %d", consecutive, scattered)
+	}
+}
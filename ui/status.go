@@ -0,0 +1,60 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+)
+
+// Styles of the status line
+var (
+	StatusLineStyle  = "+i"
+	StatusErrorStyle = "red+bi"
+)
+
+// StatusLine is a line displayed at the bottom of the terminal below the printed lines. It is erased before
+// each printed line and redrawn after it, so it always stays the last line
+type StatusLine struct {
+	out   io.Writer
+	width int
+	text  *StyledText
+}
+
+// NewStatusLine creates an empty status line, which is drawn to the terminal out with the given width
+func NewStatusLine(out io.Writer, width int) *StatusLine {
+	return &StatusLine{out: out, width: width}
+}
+
+func (s *StatusLine) draw() {
+	if s.text != nil {
+		fmt.Fprint(s.out, s.text.Truncate(s.width).Render())
+	}
+}
+
+func (s *StatusLine) erase() {
+	if s.text != nil {
+		fmt.Fprint(s.out, "\r\033[K")
+	}
+}
+
+// Update replaces the text of the status line and redraws it. The width is updated as well, in case
+// the terminal was resized
+func (s *StatusLine) Update(text *StyledText, width int) {
+	s.erase()
+	s.text = text
+	// the cursor stays after the status line, so the last column is left empty to prevent wrapping
+	s.width = width - 1
+	s.draw()
+}
+
+// Println prints the line above the status line
+func (s *StatusLine) Println(line string) {
+	s.erase()
+	fmt.Fprintln(s.out, line)
+	s.draw()
+}
+
+// Clear erases the status line, it is not drawn again until the next Update
+func (s *StatusLine) Clear() {
+	s.erase()
+	s.text = nil
+}
@@ -0,0 +1,33 @@
+package ui
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestStatusLine(t *testing.T) {
+	SetColorLevel(ColorNone)
+	defer SetColorLevel(ColorTrueColor)
+	var out bytes.Buffer
+	status := NewStatusLine(&out, 80)
+	status.Println("first")
+	status.Update(NewStyledText("3 streams, 12.5 events/s"), 11)
+	status.Println("second")
+	status.Clear()
+	status.Println("third")
+	expected := "first\n" + "3 streams," + "\r\033[K" + "second\n" + "3 streams," + "\r\033[K" + "third\n"
+	if out.String() != expected {
+		t.Errorf("\nExpected: %q\n  Actual: %q", expected, out.String())
+	}
+}
+
+func TestStyledTextTruncate(t *testing.T) {
+	text := NewStyledText("héllo ").Append("world", "red")
+	truncated := text.Truncate(8)
+	if truncated.Text() != "héllo wo" || len(truncated.spans) != 1 || truncated.spans[0].end != truncated.Len() {
+		t.Errorf("Unexpected truncated text %q %+v", truncated.Text(), truncated.spans)
+	}
+	if text.Truncate(20) != text {
+		t.Errorf("Expected short text to be unchanged")
+	}
+}
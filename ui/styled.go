@@ -0,0 +1,420 @@
+package ui
+
+import (
+	"sort"
+	"strings"
+)
+
+// Priorities of style spans. When spans overlap, foreground and background colors of the span with
+// higher priority win, attributes of all the overlapping spans are combined
+const (
+	// PriorityLine is used for styles applied to the whole line, such as log level background
+	PriorityLine = 0
+	// PriorityTheme is used for highlighting theme and color pattern
+	PriorityTheme = 10
+	// PriorityHighlight is used for explicit highlight patterns
+	PriorityHighlight = 20
+	// PriorityFilter is used for filter matches
+	PriorityFilter = 30
+	// PriorityPrefix is used for stream name and timestamp prefixes
+	PriorityPrefix = 40
+)
+
+const (
+	attrBold = 1 << iota
+	attrDim
+	attrUnderline
+	attrBlink
+	attrInverse
+	attrStrikethrough
+)
+
+var attrCodes = []struct {
+	attr uint8
+	on   string
+	off  string
+}{
+	{attrBold, "1", "22"},
+	{attrDim, "2", "22"},
+	{attrUnderline, "4", "24"},
+	{attrBlink, "5", "25"},
+	{attrInverse, "7", "27"},
+	{attrStrikethrough, "9", "29"},
+}
+
+// styleAttrLetters contains all the attribute letters accepted after '+' in styles, e.g. "red+bu":
+// bold, dim, bright color, underline, inverse and strikethrough
+const styleAttrLetters = "bdhuis"
+
+// styleAttrFlags maps attribute letters to text attributes, bright color ('h') changes the color instead
+var styleAttrFlags = []struct {
+	letter rune
+	attr   uint8
+}{{'b', attrBold}, {'d', attrDim}, {'u', attrUnderline}, {'i', attrInverse}, {'s', attrStrikethrough}}
+
+// Style is a parsed text style. Fg and Bg contain SGR parameters for foreground and background colors,
+// empty string means that the color is not set by this style
+type Style struct {
+	Fg    string
+	Bg    string
+	Attrs uint8
+}
+
+// IsEmpty returns true if the style doesn't change anything
+func (s Style) IsEmpty() bool {
+	return s.Fg == "" && s.Bg == "" && s.Attrs == 0
+}
+
+func parseStyleAttrs(attrs string, front bool) (string, uint8) {
+	if attrs == "" {
+		return "", 0
+	}
+	if attrs[0] == '#' {
+		base := "48;"
+		if front {
+			base = "38;"
+		}
+		code := base + colorRGB(int(hexToRGB(attrs)))
+		return code[:len(code)-1], 0
+	}
+	attrGroup := strings.Split(attrs, "+")
+	var color string
+	var c256 bool
+	code, ok := colorsTerm[attrGroup[0]]
+	if !ok {
+		if code, ok = colorNames256[attrGroup[0]]; !ok {
+			color = "9"
+		} else {
+			color = "5;" + code
+			c256 = true
+		}
+	} else {
+		color = code
+	}
+	var style string
+	if len(attrGroup) > 1 {
+		style = attrGroup[1]
+	}
+	var colorBase string
+	if c256 {
+		if front {
+			colorBase = "38;"
+		} else {
+			colorBase = "48;"
+		}
+	} else if strings.Contains(style, "h") {
+		colorBase = brightColorCode[front]
+	} else {
+		colorBase = colorCode[front]
+	}
+	var flags uint8
+	if front {
+		for _, a := range styleAttrFlags {
+			if strings.ContainsRune(style, a.letter) {
+				flags |= a.attr
+			}
+		}
+	}
+	return colorBase + color, flags
+}
+
+// ParseStyle parses style in "foreground+attributes:background" format, e.g. "red+bu:blue+h".
+// Colors are basic terminal colors, 256 color names or #RRGGBB, attributes are bold, dim, bright color,
+// underline, inverse and strikethrough
+func ParseStyle(styleCode string) Style {
+	style := strings.ToLower(strings.TrimSpace(styleCode))
+	if style == "" {
+		return Style{}
+	}
+	if style == "reset" {
+		return Style{Fg: "39", Bg: "49"}
+	}
+	fgBg := strings.Split(style, ":")
+	var result Style
+	result.Fg, result.Attrs = parseStyleAttrs(fgBg[0], true)
+	if len(fgBg) > 1 {
+		result.Bg, _ = parseStyleAttrs(fgBg[1], false)
+	}
+	return result
+}
+
+type span struct {
+	start    int
+	end      int
+	style    Style
+	priority int
+	order    int
+}
+
+// StyledText is a text with style spans. Highlighters add spans which refer to the positions in the raw text,
+// and Render converts the text and all the spans into a string with ANSI escape sequences
+type StyledText struct {
+	text  string
+	spans []span
+}
+
+// NewStyledText creates a styled text without any styles
+func NewStyledText(text string) *StyledText {
+	return &StyledText{text: text}
+}
+
+// Text returns the text without styles
+func (t *StyledText) Text() string {
+	return t.text
+}
+
+// Len returns the length of the text in bytes
+func (t *StyledText) Len() int {
+	return len(t.text)
+}
+
+// AddSpan applies the style to the text between start and end byte offsets
+func (t *StyledText) AddSpan(start int, end int, style Style, priority int) *StyledText {
+	if start < 0 {
+		start = 0
+	}
+	if end > len(t.text) {
+		end = len(t.text)
+	}
+	if start >= end || style.IsEmpty() {
+		return t
+	}
+	t.spans = append(t.spans, span{
+		start:    start,
+		end:      end,
+		style:    style,
+		priority: priority,
+		order:    len(t.spans),
+	})
+	return t
+}
+
+// AddStyle applies the style in ColorCode format to the text between start and end byte offsets
+func (t *StyledText) AddStyle(start int, end int, style string, priority int) *StyledText {
+	return t.AddSpan(start, end, ParseStyle(style), priority)
+}
+
+// Append adds text with the style to the end of this text
+func (t *StyledText) Append(text string, style string) *StyledText {
+	start := len(t.text)
+	t.text += text
+	return t.AddStyle(start, len(t.text), style, PriorityPrefix)
+}
+
+// AppendText adds other styled text to the end of this text, keeping its styles
+func (t *StyledText) AppendText(other *StyledText) *StyledText {
+	offset := len(t.text)
+	t.text += other.text
+	for _, s := range other.spans {
+		t.spans = append(t.spans, span{
+			start:    s.start + offset,
+			end:      s.end + offset,
+			style:    s.style,
+			priority: s.priority,
+			order:    len(t.spans),
+		})
+	}
+	return t
+}
+
+// LineRanges returns start and end byte offsets of each line of the text, without newline characters
+func LineRanges(text string) [][2]int {
+	var result [][2]int
+	start := 0
+	for pos := strings.IndexByte(text, '\n'); pos >= 0; pos = nextNewline(text, pos) {
+		result = append(result, [2]int{start, pos})
+		start = pos + 1
+	}
+	return append(result, [2]int{start, len(text)})
+}
+
+// nextNewline returns the position of the newline following the one at pos, or -1
+func nextNewline(text string, pos int) int {
+	next := strings.IndexByte(text[pos+1:], '\n')
+	if next < 0 {
+		return -1
+	}
+	return pos + 1 + next
+}
+
+// Lines splits the text into physical lines, spans are clipped to the lines they cover
+func (t *StyledText) Lines() []*StyledText {
+	ranges := LineRanges(t.text)
+	result := make([]*StyledText, 0, len(ranges))
+	for _, r := range ranges {
+		result = append(result, t.slice(r[0], r[1]))
+	}
+	return result
+}
+
+// slice returns the part of the text between start and end byte offsets, with spans clipped to it
+func (t *StyledText) slice(from int, to int) *StyledText {
+	result := NewStyledText(t.text[from:to])
+	for _, s := range t.spans {
+		start, end := s.start, s.end
+		if start < from {
+			start = from
+		}
+		if end > to {
+			end = to
+		}
+		if start < end {
+			result.spans = append(result.spans, span{
+				start:    start - from,
+				end:      end - from,
+				style:    s.style,
+				priority: s.priority,
+				order:    s.order,
+			})
+		}
+	}
+	return result
+}
+
+// Truncate returns the text cut to at most width runes
+func (t *StyledText) Truncate(width int) *StyledText {
+	runes := 0
+	for i := range t.text {
+		if runes == width {
+			return t.slice(0, i)
+		}
+		runes++
+	}
+	return t
+}
+
+// ReplaceNewlines replaces each newline with the replacement displayed with the style, keeping styles
+// of the rest of the text
+func (t *StyledText) ReplaceNewlines(replacement string, style string) *StyledText {
+	if !strings.Contains(t.text, "\n") {
+		return t
+	}
+	// offsets maps each position in the old text to the position in the new text
+	offsets := make([]int, len(t.text)+1)
+	var sb strings.Builder
+	var replaced []int
+	for i := 0; i < len(t.text); i++ {
+		offsets[i] = sb.Len()
+		if t.text[i] == '\n' {
+			replaced = append(replaced, sb.Len())
+			sb.WriteString(replacement)
+		} else {
+			sb.WriteByte(t.text[i])
+		}
+	}
+	offsets[len(t.text)] = sb.Len()
+	t.text = sb.String()
+	for i := range t.spans {
+		t.spans[i].start = offsets[t.spans[i].start]
+		t.spans[i].end = offsets[t.spans[i].end]
+	}
+	for _, pos := range replaced {
+		t.AddStyle(pos, pos+len(replacement), style, PriorityPrefix)
+	}
+	return t
+}
+
+// styleAt calculates effective style of the text at the position
+func (t *StyledText) styleAt(pos int, ordered []span) Style {
+	var result Style
+	for _, s := range ordered {
+		if pos < s.start || pos >= s.end {
+			continue
+		}
+		if s.style.Fg != "" {
+			result.Fg = s.style.Fg
+		}
+		if s.style.Bg != "" {
+			result.Bg = s.style.Bg
+		}
+		result.Attrs |= s.style.Attrs
+	}
+	return result
+}
+
+// transition returns SGR parameters required to change style from one to another
+func transition(from Style, to Style) []string {
+	var codes []string
+	if from.Fg != to.Fg {
+		if to.Fg == "" {
+			codes = append(codes, "39")
+		} else {
+			codes = append(codes, to.Fg)
+		}
+	}
+	removed := from.Attrs &^ to.Attrs
+	added := to.Attrs &^ from.Attrs
+	if removed&(attrBold|attrDim) != 0 {
+		// there is a single code to turn off both bold and dim, so remaining one must be turned on again
+		added |= to.Attrs & (attrBold | attrDim)
+	}
+	for _, a := range attrCodes {
+		if removed&a.attr != 0 && (a.attr != attrDim || removed&attrBold == 0) {
+			codes = append(codes, a.off)
+		}
+	}
+	for _, a := range attrCodes {
+		if added&a.attr != 0 {
+			codes = append(codes, a.on)
+		}
+	}
+	if from.Bg != to.Bg {
+		if to.Bg == "" {
+			codes = append(codes, "49")
+		} else {
+			codes = append(codes, to.Bg)
+		}
+	}
+	return codes
+}
+
+// Render converts the text into a string with ANSI escape sequences. Escape sequences are emitted only where
+// the effective style changes, and all the styles are reset at the end of the text
+func (t *StyledText) Render() string {
+	if len(t.spans) == 0 || colorLevel == ColorNone {
+		return t.text
+	}
+	ordered := make([]span, len(t.spans))
+	copy(ordered, t.spans)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if ordered[i].priority != ordered[j].priority {
+			return ordered[i].priority < ordered[j].priority
+		}
+		return ordered[i].order < ordered[j].order
+	})
+
+	boundarySet := map[int]bool{0: true, len(t.text): true}
+	for _, s := range ordered {
+		boundarySet[s.start] = true
+		boundarySet[s.end] = true
+	}
+	for pos := strings.IndexByte(t.text, '\n'); pos >= 0; pos = nextNewline(t.text, pos) {
+		boundarySet[pos] = true
+		boundarySet[pos+1] = true
+	}
+	boundaries := make([]int, 0, len(boundarySet))
+	for b := range boundarySet {
+		boundaries = append(boundaries, b)
+	}
+	sort.Ints(boundaries)
+
+	var sb strings.Builder
+	var current Style
+	for i := 0; i < len(boundaries)-1; i++ {
+		start := boundaries[i]
+		next := t.styleAt(start, ordered)
+		if t.text[start] == '\n' {
+			// styles end with the physical line, so backgrounds don't spill over the rest of the terminal line
+			next = Style{}
+		}
+		if codes := transition(current, next); len(codes) > 0 {
+			sb.WriteString(adaptSequence(escape + strings.Join(codes, ";") + finalizer))
+		}
+		current = next
+		sb.WriteString(t.text[start:boundaries[i+1]])
+	}
+	if codes := transition(current, Style{}); len(codes) > 0 {
+		sb.WriteString(adaptSequence(escape + strings.Join(codes, ";") + finalizer))
+	}
+	return sb.String()
+}
@@ -0,0 +1,182 @@
+package ui
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestParseStyle(t *testing.T) {
+	tests := []struct {
+		style    string
+		expected Style
+	}{
+		{"red+bu:blue+h", Style{Fg: "31", Bg: "104", Attrs: attrBold | attrUnderline}},
+		{":yellow", Style{Bg: "43"}},
+		{"RosyBrown:DodgerBlue", Style{Fg: "38;5;138", Bg: "48;5;33"}},
+		{"plum+b", Style{Fg: "38;5;183", Attrs: attrBold}},
+		{"#FF1001:#0110FF", Style{Fg: "38;2;255;16;1", Bg: "48;2;1;16;255"}},
+		{"+i", Style{Fg: "39", Attrs: attrInverse}},
+		{"reset", Style{Fg: "39", Bg: "49"}},
+		{"", Style{}},
+	}
+	for _, test := range tests {
+		actual := ParseStyle(test.style)
+		if actual != test.expected {
+			t.Errorf("'%s'\nExpected: %+v\n  Actual: %+v", test.style, test.expected, actual)
+		}
+	}
+}
+
+func TestRenderWithoutSpans(t *testing.T) {
+	actual := NewStyledText("plain").Render()
+	if actual != "plain" {
+		t.Errorf("Expected: plain\n  Actual: %v", actual)
+	}
+}
+
+func TestLevelBackgroundSurvivesInnerHighlights(t *testing.T) {
+	text := NewStyledText("error at 12:00 in [main]")
+	text.AddStyle(0, text.Len(), ":red", PriorityLine)
+	DarkTheme.Apply(text, PriorityTheme)
+	actual := text.Render()
+	expected := "\033[38;5;183;41merror\033[39m at \033[38;5;136m12:00\033[39m in [\033[38;5;36mmain\033[39m]\033[49m"
+	if actual != expected {
+		t.Errorf("\nExpected: %q\n  Actual: %q", expected, actual)
+	}
+}
+
+func TestFilterMatchComposesWithColorPattern(t *testing.T) {
+	text := NewStyledText("user=john failed")
+	// filter match is computed on the raw message and stays valid after other highlighters run
+	text.AddStyle(5, 9, ":cyan", PriorityFilter)
+	ColorizeText(regexp.MustCompile(`(\w+)=(\w+)`), text, PriorityTheme)
+	actual := text.Render()
+	expected := "\033[32muser\033[39m=\033[33;46mjohn\033[39;49m failed"
+	if actual != expected {
+		t.Errorf("\nExpected: %q\n  Actual: %q", expected, actual)
+	}
+}
+
+func TestHigherPriorityForegroundWins(t *testing.T) {
+	text := NewStyledText("abcdef")
+	text.AddStyle(0, 6, "red", PriorityTheme)
+	text.AddStyle(2, 4, "blue+b", PriorityHighlight)
+	actual := text.Render()
+	expected := "\033[31mab\033[34;1mcd\033[31;22mef\033[39m"
+	if actual != expected {
+		t.Errorf("\nExpected: %q\n  Actual: %q", expected, actual)
+	}
+}
+
+func TestBoldAndDimAreRestoredSeparately(t *testing.T) {
+	text := NewStyledText("abc")
+	text.AddStyle(0, 3, "+d", PriorityLine)
+	text.AddStyle(1, 2, "+b", PriorityTheme)
+	actual := text.Render()
+	expected := "\033[39;2ma\033[1mb\033[22;2mc\033[39;22m"
+	if actual != expected {
+		t.Errorf("\nExpected: %q\n  Actual: %q", expected, actual)
+	}
+}
+
+func TestAppendText(t *testing.T) {
+	message := NewStyledText("message")
+	message.AddStyle(0, 3, "red", PriorityTheme)
+	line := NewStyledText("[").Append("ts", "green").Append("] ", "")
+	actual := line.AppendText(message).Render()
+	expected := "[\033[32mts\033[39m] \033[31mmes\033[39msage"
+	if actual != expected {
+		t.Errorf("\nExpected: %q\n  Actual: %q", expected, actual)
+	}
+}
+
+func TestStylesEndWithPhysicalLine(t *testing.T) {
+	text := NewStyledText("error\n  at main")
+	text.AddStyle(0, text.Len(), ":red", PriorityLine)
+	text.AddStyle(4, 9, "yellow", PriorityHighlight)
+	actual := text.Render()
+	expected := "\033[41merro\033[33mr\033[39;49m\n\033[33;41m  a\033[39mt main\033[49m"
+	if actual != expected {
+		t.Errorf("\nExpected: %q\n  Actual: %q", expected, actual)
+	}
+}
+
+func TestLines(t *testing.T) {
+	text := NewStyledText("first\nsecond\n")
+	text.AddStyle(3, 9, "red", PriorityTheme)
+	lines := text.Lines()
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 lines, got %d", len(lines))
+	}
+	expected := []string{"fir\033[31mst\033[39m", "\033[31msec\033[39mond", ""}
+	for i, line := range lines {
+		if actual := line.Render(); actual != expected[i] {
+			t.Errorf("line %d\nExpected: %q\n  Actual: %q", i, expected[i], actual)
+		}
+	}
+}
+
+func TestReplaceNewlines(t *testing.T) {
+	text := NewStyledText("a\nbc\nd")
+	text.AddStyle(0, 4, "red", PriorityTheme)
+	actual := text.ReplaceNewlines(`\n`, "+d").Render()
+	expected := "\033[31ma\033[39;2m\\n\033[31;22mbc\033[39;2m\\n\033[39;22md"
+	if actual != expected {
+		t.Errorf("\nExpected: %q\n  Actual: %q", expected, actual)
+	}
+}
+
+func TestPrefixLines(t *testing.T) {
+	prefix := NewStyledText("").Append("[stream]", "").Append(" ", "")
+	message := NewStyledText("Traceback:\n  File x\nValueError")
+	actual := PrefixLines(prefix, message).Text()
+	expected := "[stream] Traceback:\n       │   File x\n       │ ValueError"
+	if actual != expected {
+		t.Errorf("\nExpected: %q\n  Actual: %q", expected, actual)
+	}
+	actual = PrefixLines(NewStyledText(""), NewStyledText("a\nb")).Text()
+	if actual != "a\n  b" {
+		t.Errorf("Expected indented continuation line, got %q", actual)
+	}
+}
+
+func TestLineStyled(t *testing.T) {
+	line := &Line{Message: NewStyledText("a\nb"), Context: true}
+	actual := line.Styled(NewStyledText("[s] ")).Render()
+	expected := "\033[39;2m[s] a\033[39;22m\n\033[39;2m  │ b\033[39;22m"
+	if actual != expected {
+		t.Errorf("\nExpected: %q\n  Actual: %q", expected, actual)
+	}
+	separator := &Line{Message: NewStyledText("--"), Separator: true}
+	if actual = separator.Styled(NewStyledText("[s] ")).Text(); actual != "--" {
+		t.Errorf("Separator must be displayed without prefix, got %q", actual)
+	}
+}
+
+func TestColorizeTextMatchesEveryLine(t *testing.T) {
+	text := NewStyledText("id=1 id=2\nid=3")
+	ColorizeText(regexp.MustCompile(`id=(\d)`), text, PriorityTheme)
+	actual := text.Render()
+	expected := "id=\033[32m1\033[39m id=\033[32m2\033[39m\nid=\033[32m3\033[39m"
+	if actual != expected {
+		t.Errorf("\nExpected: %q\n  Actual: %q", expected, actual)
+	}
+}
+
+func TestThemeMatchesEachLine(t *testing.T) {
+	theme := MustCompileTheme(ThemeDefinition{Rules: []ThemeRule{{Pattern: `\[([^]]+)]`, Groups: []string{"red"}}}})
+	actual := theme.Colorize("[a\nb] [c]")
+	expected := "[a\nb] [\033[31mc\033[39m]"
+	if actual != expected {
+		t.Errorf("\nExpected: %q\n  Actual: %q", expected, actual)
+	}
+}
+
+func TestSeparator(t *testing.T) {
+	if text := Separator("5m0s gap", 20).Text(); text != "── 5m0s gap ────────" {
+		t.Errorf("Unexpected separator %q", text)
+	}
+	if text := Separator("a long label", 5).Text(); text != "── a long label " {
+		t.Errorf("Unexpected separator %q", text)
+	}
+}
@@ -0,0 +1,209 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// ColorLevel is the set of colors supported by the terminal
+type ColorLevel int
+
+// Color levels in the order of increasing capability
+const (
+	// ColorNone disables all escape sequences
+	ColorNone ColorLevel = iota
+	// Color16 supports 8 basic colors and their bright variants
+	Color16
+	// Color256 supports xterm 256-color palette
+	Color256
+	// ColorTrueColor supports 24-bit colors
+	ColorTrueColor
+)
+
+// Color modes accepted by DetectColorLevel
+const (
+	ColorModeAuto   = "auto"
+	ColorModeAlways = "always"
+	ColorModeNever  = "never"
+)
+
+var colorLevel = ColorTrueColor
+
+// palette16 contains RGB values of the basic and bright colors, as used by xterm
+var palette16 = []uint{
+	0x000000, 0xCD0000, 0x00CD00, 0xCDCD00, 0x0000EE, 0xCD00CD, 0x00CDCD, 0xE5E5E5,
+	0x7F7F7F, 0xFF0000, 0x00FF00, 0xFFFF00, 0x5C5CFF, 0xFF00FF, 0x00FFFF, 0xFFFFFF,
+}
+
+var cubeLevels = []uint{0, 95, 135, 175, 215, 255}
+
+// SetColorLevel sets the color capability used for all the escape sequences produced by this package
+func SetColorLevel(level ColorLevel) {
+	colorLevel = level
+}
+
+// GetColorLevel returns the current color capability
+func GetColorLevel() ColorLevel {
+	return colorLevel
+}
+
+// StdoutIsTerminal returns true if standard output is a terminal
+func StdoutIsTerminal() bool {
+	return term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+func terminalCapability(getenv func(string) string) ColorLevel {
+	colorTerm := strings.ToLower(getenv("COLORTERM"))
+	termName := strings.ToLower(getenv("TERM"))
+	switch {
+	case colorTerm == "truecolor" || colorTerm == "24bit":
+		return ColorTrueColor
+	case strings.Contains(termName, "truecolor") || strings.Contains(termName, "direct"):
+		return ColorTrueColor
+	case strings.Contains(termName, "256color"):
+		return Color256
+	}
+	return Color16
+}
+
+// DetectColorLevel determines colors supported by the terminal. In auto mode colors are disabled if output is not
+// a terminal, NO_COLOR environment variable is set or TERM is dumb. In always mode at least 16 colors are used
+func DetectColorLevel(mode string, isTerminal bool, getenv func(string) string) (ColorLevel, error) {
+	switch strings.ToLower(mode) {
+	case ColorModeNever:
+		return ColorNone, nil
+	case ColorModeAlways:
+		return terminalCapability(getenv), nil
+	case ColorModeAuto, "":
+		if !isTerminal || getenv("NO_COLOR") != "" || strings.ToLower(getenv("TERM")) == "dumb" {
+			return ColorNone, nil
+		}
+		return terminalCapability(getenv), nil
+	}
+	return ColorNone, fmt.Errorf("invalid color mode '%s', expected auto, always or never", mode)
+}
+
+func colorDistance(c1 uint, c2 uint) uint {
+	dr := int((c1>>16)&0xFF) - int((c2>>16)&0xFF)
+	dg := int((c1>>8)&0xFF) - int((c2>>8)&0xFF)
+	db := int(c1&0xFF) - int(c2&0xFF)
+	return uint(dr*dr + dg*dg + db*db)
+}
+
+// ansi256ToRGB converts index in the xterm 256-color palette into RGB color
+func ansi256ToRGB(index int) uint {
+	switch {
+	case index < 16:
+		return palette16[index]
+	case index < 232:
+		i := index - 16
+		return RGB(cubeLevels[i/36], cubeLevels[(i/6)%6], cubeLevels[i%6])
+	default:
+		grey := uint(8 + (index-232)*10)
+		return RGB(grey, grey, grey)
+	}
+}
+
+func nearestIndex(color uint, first int, last int) int {
+	best := first
+	bestDistance := ^uint(0)
+	for i := first; i <= last; i++ {
+		if d := colorDistance(color, ansi256ToRGB(i)); d < bestDistance {
+			best = i
+			bestDistance = d
+		}
+	}
+	return best
+}
+
+// rgbTo256 finds the nearest color in xterm 256-color palette, excluding the system colors
+func rgbTo256(color uint) int {
+	return nearestIndex(color, 16, 255)
+}
+
+// rgbTo16 finds the nearest basic or bright color
+func rgbTo16(color uint) int {
+	return nearestIndex(color, 0, 15)
+}
+
+func basicColorCode(index int, front bool) string {
+	base := 30
+	if !front {
+		base = 40
+	}
+	if index >= 8 {
+		base += 60
+		index -= 8
+	}
+	return strconv.Itoa(base + index)
+}
+
+// downgradeParams converts SGR parameters using 24-bit or 256 colors into parameters supported by colorLevel
+func downgradeParams(params []string) []string {
+	result := make([]string, 0, len(params))
+	for i := 0; i < len(params); i++ {
+		p := params[i]
+		if (p != "38" && p != "48") || i+1 >= len(params) {
+			result = append(result, p)
+			continue
+		}
+		front := p == "38"
+		var color uint
+		var index = -1
+		switch {
+		case params[i+1] == "5" && i+2 < len(params):
+			n, _ := strconv.Atoi(params[i+2])
+			index = n
+			color = ansi256ToRGB(n)
+			i += 2
+		case params[i+1] == "2" && i+4 < len(params):
+			r, _ := strconv.Atoi(params[i+2])
+			g, _ := strconv.Atoi(params[i+3])
+			b, _ := strconv.Atoi(params[i+4])
+			color = RGB(uint(r), uint(g), uint(b))
+			i += 4
+		default:
+			result = append(result, p)
+			continue
+		}
+		switch {
+		case colorLevel >= ColorTrueColor && index < 0:
+			result = append(result, p, "2", strconv.Itoa(int(color>>16&0xFF)), strconv.Itoa(int(color>>8&0xFF)), strconv.Itoa(int(color&0xFF)))
+		case colorLevel >= Color256 && index >= 0:
+			result = append(result, p, "5", strconv.Itoa(index))
+		case colorLevel >= Color256:
+			result = append(result, p, "5", strconv.Itoa(rgbTo256(color)))
+		case index >= 0 && index < 16:
+			result = append(result, basicColorCode(index, front))
+		default:
+			result = append(result, basicColorCode(rgbTo16(color), front))
+		}
+	}
+	return result
+}
+
+// adaptSequence adapts the escape sequence to the color capability of the terminal
+func adaptSequence(sequence string) string {
+	if colorLevel == ColorNone {
+		return ""
+	}
+	if colorLevel == ColorTrueColor || !strings.HasPrefix(sequence, escape) || !strings.HasSuffix(sequence, finalizer) {
+		return sequence
+	}
+	params := strings.Split(sequence[len(escape):len(sequence)-len(finalizer)], ";")
+	return escape + strings.Join(downgradeParams(params), ";") + finalizer
+}
+
+// TerminalSize returns width and height of the terminal connected to standard output, or 80x24 if it is not
+// a terminal
+func TerminalSize() (int, int) {
+	width, height, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil || width <= 0 || height <= 0 {
+		return 80, 24
+	}
+	return width, height
+}
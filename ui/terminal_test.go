@@ -0,0 +1,105 @@
+package ui
+
+import (
+	"testing"
+)
+
+func envFunc(env map[string]string) func(string) string {
+	return func(name string) string {
+		return env[name]
+	}
+}
+
+func withColorLevel(level ColorLevel, f func()) {
+	saved := colorLevel
+	SetColorLevel(level)
+	defer SetColorLevel(saved)
+	f()
+}
+
+func TestDetectColorLevel(t *testing.T) {
+	tests := []struct {
+		mode       string
+		isTerminal bool
+		env        map[string]string
+		expected   ColorLevel
+	}{
+		{"auto", true, map[string]string{"TERM": "xterm"}, Color16},
+		{"auto", true, map[string]string{"TERM": "xterm-256color"}, Color256},
+		{"auto", true, map[string]string{"TERM": "xterm-256color", "COLORTERM": "truecolor"}, ColorTrueColor},
+		{"auto", true, map[string]string{"TERM": "xterm-direct"}, ColorTrueColor},
+		{"auto", false, map[string]string{"TERM": "xterm-256color"}, ColorNone},
+		{"auto", true, map[string]string{"TERM": "xterm-256color", "NO_COLOR": "1"}, ColorNone},
+		{"auto", true, map[string]string{"TERM": "dumb"}, ColorNone},
+		{"always", false, map[string]string{"TERM": "xterm-256color"}, Color256},
+		{"always", false, map[string]string{}, Color16},
+		{"never", true, map[string]string{"COLORTERM": "truecolor"}, ColorNone},
+	}
+	for _, test := range tests {
+		actual, err := DetectColorLevel(test.mode, test.isTerminal, envFunc(test.env))
+		if err != nil || actual != test.expected {
+			t.Errorf("%s %v %v: expected %v, got %v %v", test.mode, test.isTerminal, test.env, test.expected, actual, err)
+		}
+	}
+	if _, err := DetectColorLevel("sometimes", true, envFunc(nil)); err == nil {
+		t.Errorf("Expected error for invalid mode")
+	}
+}
+
+func TestDowngradeTo256(t *testing.T) {
+	withColorLevel(Color256, func() {
+		tests := []struct {
+			style    string
+			expected string
+		}{
+			{"#FF8700:#000000", "\033[38;5;208;48;5;16m"},
+			{"RosyBrown:DodgerBlue", "\033[38;5;138;48;5;33m"},
+			{"red+bu:blue+h", "\033[31;1;4;104m"},
+		}
+		for _, test := range tests {
+			actual := ColorCode(test.style)
+			if actual != test.expected {
+				t.Errorf("%s\nExpected: %q\n  Actual: %q", test.style, test.expected, actual)
+			}
+		}
+	})
+}
+
+func TestDowngradeTo16(t *testing.T) {
+	withColorLevel(Color16, func() {
+		tests := []struct {
+			style    string
+			expected string
+		}{
+			{"#FF0000:#0000EE", "\033[91;44m"},
+			{"RosyBrown:DodgerBlue", "\033[90;46m"},
+			{"red+bu:blue+h", "\033[31;1;4;104m"},
+		}
+		for _, test := range tests {
+			actual := ColorCode(test.style)
+			if actual != test.expected {
+				t.Errorf("%s\nExpected: %q\n  Actual: %q", test.style, test.expected, actual)
+			}
+		}
+		actual := Color256Wrap("text", "darkcyan")
+		expected := "\033[36mtext\033[39;49m"
+		if actual != expected {
+			t.Errorf("\nExpected: %q\n  Actual: %q", expected, actual)
+		}
+	})
+}
+
+func TestNoColor(t *testing.T) {
+	withColorLevel(ColorNone, func() {
+		if actual := ColorWrap("text", "red:blue"); actual != "text" {
+			t.Errorf("Expected plain text, got %q", actual)
+		}
+		if actual := TimestampColorizer("12:00"); actual != "12:00" {
+			t.Errorf("Expected plain text, got %q", actual)
+		}
+		text := NewStyledText("error").AddStyle(0, 5, ":red", PriorityLine)
+		if actual := text.Render(); actual != "error" {
+			t.Errorf("Expected plain text, got %q", actual)
+		}
+	})
+}
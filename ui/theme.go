@@ -0,0 +1,212 @@
+package ui
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/dlclark/regexp2"
+	"gopkg.in/yaml.v2"
+)
+
+// ThemeRule is a single highlighting rule of a theme. If Groups are provided then each capturing group of the
+// Pattern is displayed with the corresponding style from Groups, otherwise the whole match is displayed with Style
+type ThemeRule struct {
+	Pattern       string   `yaml:"pattern"`
+	Style         string   `yaml:"style"`
+	Groups        []string `yaml:"groups"`
+	CaseSensitive bool     `yaml:"case-sensitive"`
+}
+
+// ThemeDefinition is a set of highlighting rules, as stored in the theme file
+type ThemeDefinition struct {
+	Rules []ThemeRule `yaml:"rules"`
+}
+
+type compiledRule struct {
+	re     *regexp2.Regexp
+	style  string
+	groups []string
+}
+
+// Theme highlights parts of the log message according to the set of rules.
+// When matches of several rules overlap, the match which starts first wins, and if they start at the same
+// position then the rule listed first wins
+type Theme struct {
+	rules []compiledRule
+}
+
+var (
+	// DarkTheme is a built-in theme for terminals with dark background
+	DarkTheme = MustCompileTheme(ThemeDefinition{Rules: []ThemeRule{
+		{Pattern: `\d{2}(?:[-:.]\d{2,3}){1,3}`, Style: "darkgoldenrod"},
+		{Pattern: `\[([^]]+)]`, Groups: []string{"darkcyan"}},
+		{Pattern: `\s+((?:"[^"]+")|(?:'[^']+'))\s+`, Groups: []string{"royalblue"}},
+		{Pattern: `\b(?:info|error|warn|trace|debug|warning)\b`, Style: "plum"},
+		{Pattern: `\b\d[\d.]+\b`, Style: "navajowhite"},
+		{Pattern: `([\p{L}\d._]+)(?:=|:)(['"]?[\p{L}\d._]+)['"]?`, Groups: []string{"turquoise", "lightseagreen"}},
+	}})
+
+	// LightTheme is a built-in theme for terminals with light background
+	LightTheme = MustCompileTheme(ThemeDefinition{Rules: []ThemeRule{
+		{Pattern: `\d{2}(?:[-:.]\d{2,3}){1,3}`, Style: "darkgoldenrod"},
+		{Pattern: `\[([^]]+)]`, Groups: []string{"darkcyan"}},
+		{Pattern: `\s+((?:"[^"]+")|(?:'[^']+'))\s+`, Groups: []string{"navyblue"}},
+		{Pattern: `\b(?:info|error|warn|trace|debug|warning)\b`, Style: "darkmagenta"},
+		{Pattern: `\b\d[\d.]+\b`, Style: "darkred"},
+		{Pattern: `([\p{L}\d._]+)(?:=|:)(['"]?[\p{L}\d._]+)['"]?`, Groups: []string{"darkblue", "darkgreen"}},
+	}})
+
+	builtinThemes = map[string]*Theme{
+		"dark":  DarkTheme,
+		"light": LightTheme,
+	}
+)
+
+// CompileTheme validates the theme definition and compiles its rules.
+// Patterns are case-insensitive unless the rule is marked as case-sensitive
+func CompileTheme(def ThemeDefinition) (*Theme, error) {
+	theme := &Theme{}
+	for i, rule := range def.Rules {
+		var reOptions regexp2.RegexOptions
+		if !rule.CaseSensitive {
+			reOptions = regexp2.IgnoreCase
+		}
+		re, err := regexp2.Compile(rule.Pattern, reOptions)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: invalid pattern: %v", i+1, err)
+		}
+		if rule.Style == "" && len(rule.Groups) == 0 {
+			return nil, fmt.Errorf("rule %d: either style or groups must be provided", i+1)
+		}
+		if err = ValidateStyle(rule.Style); err != nil {
+			return nil, fmt.Errorf("rule %d: %v", i+1, err)
+		}
+		for _, style := range rule.Groups {
+			if err = ValidateStyle(style); err != nil {
+				return nil, fmt.Errorf("rule %d: %v", i+1, err)
+			}
+		}
+		theme.rules = append(theme.rules, compiledRule{
+			re:     re,
+			style:  rule.Style,
+			groups: rule.Groups,
+		})
+	}
+	return theme, nil
+}
+
+// MustCompileTheme is like CompileTheme, but panics if the theme is invalid
+func MustCompileTheme(def ThemeDefinition) *Theme {
+	theme, err := CompileTheme(def)
+	if err != nil {
+		panic(err)
+	}
+	return theme
+}
+
+// ParseHighlightRule parses a case-sensitive rule in PATTERN=style format. Style is separated from the pattern
+// by the last '=' character, so the pattern itself can contain '='
+func ParseHighlightRule(spec string) (ThemeRule, error) {
+	sep := strings.LastIndex(spec, "=")
+	if sep <= 0 || sep == len(spec)-1 {
+		return ThemeRule{}, fmt.Errorf("invalid highlight '%s', expected PATTERN=style", spec)
+	}
+	return ThemeRule{
+		Pattern:       spec[:sep],
+		Style:         spec[sep+1:],
+		CaseSensitive: true,
+	}, nil
+}
+
+// ParseTheme parses YAML theme definition
+func ParseTheme(data []byte) (*Theme, error) {
+	var def ThemeDefinition
+	if err := yaml.UnmarshalStrict(data, &def); err != nil {
+		return nil, err
+	}
+	return CompileTheme(def)
+}
+
+// LoadTheme returns built-in theme by name, or loads the theme from the file
+func LoadTheme(nameOrPath string) (*Theme, error) {
+	if theme, ok := builtinThemes[strings.ToLower(nameOrPath)]; ok {
+		return theme, nil
+	}
+	data, err := ioutil.ReadFile(nameOrPath)
+	if err != nil {
+		return nil, fmt.Errorf("'%s' is neither a built-in theme nor a readable theme file: %v", nameOrPath, err)
+	}
+	theme, err := ParseTheme(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid theme %s: %v", nameOrPath, err)
+	}
+	return theme, nil
+}
+
+// findNext finds the next match of any rule starting at or after pos
+func (t *Theme) findNext(text []rune, pos int) (*regexp2.Match, *compiledRule) {
+	var best *regexp2.Match
+	var bestRule *compiledRule
+	for i := range t.rules {
+		m, err := t.rules[i].re.FindRunesMatchStartingAt(text, pos)
+		if err != nil || m == nil || m.Length == 0 {
+			continue
+		}
+		if best == nil || m.Index < best.Index {
+			best = m
+			bestRule = &t.rules[i]
+		}
+	}
+	return best, bestRule
+}
+
+// Apply adds style spans to the text for parts matching theme rules. Each line of the text is matched separately
+func (t *Theme) Apply(text *StyledText, priority int) {
+	runes := []rune(text.Text())
+	// regexp2 works with rune indices, spans use byte offsets
+	offsets := make([]int, len(runes)+1)
+	offset := 0
+	for i, r := range runes {
+		offsets[i] = offset
+		offset += len(string(r))
+	}
+	offsets[len(runes)] = offset
+
+	lineStart := 0
+	for i := 0; i <= len(runes); i++ {
+		if i == len(runes) || runes[i] == '\n' {
+			t.applyLine(text, runes[lineStart:i], offsets[lineStart:i+1], priority)
+			lineStart = i + 1
+		}
+	}
+}
+
+// applyLine adds style spans for the line, offsets contain byte offsets of the line runes in the text
+func (t *Theme) applyLine(text *StyledText, line []rune, offsets []int, priority int) {
+	pos := 0
+	for pos < len(line) {
+		m, rule := t.findNext(line, pos)
+		if m == nil {
+			break
+		}
+		if len(rule.groups) == 0 {
+			text.AddStyle(offsets[m.Index], offsets[m.Index+m.Length], rule.style, priority)
+		} else {
+			for gi, group := range m.Groups()[1:] {
+				if gi >= len(rule.groups) || len(group.Captures) == 0 {
+					continue
+				}
+				text.AddStyle(offsets[group.Index], offsets[group.Index+group.Length], rule.groups[gi], priority)
+			}
+		}
+		pos = m.Index + m.Length
+	}
+}
+
+// Colorize highlights parts of the text matching theme rules
+func (t *Theme) Colorize(text string) string {
+	styled := NewStyledText(text)
+	t.Apply(styled, PriorityTheme)
+	return styled.Render()
+}
@@ -0,0 +1,179 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseTheme(t *testing.T) {
+	theme, err := ParseTheme([]byte(`
+rules:
+  - pattern: 'order-\d+'
+    style: 'red+b'
+  - pattern: '(\w+)=(\w+)'
+    groups: [cyan, '#FF8800']
+`))
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	actual := theme.Colorize("placed order-15 by user=john")
+	expected := "placed \033[31;1morder-15\033[39;22m by \033[36muser\033[39m=\033[38;2;255;136;0mjohn\033[39m"
+	if actual != expected {
+		t.Errorf("\nExpected: %v\n  Actual: %v", expected, actual)
+	}
+}
+
+func TestThemeFirstRuleWinsOnSamePosition(t *testing.T) {
+	theme := MustCompileTheme(ThemeDefinition{Rules: []ThemeRule{
+		{Pattern: `error`, Style: "red"},
+		{Pattern: `\w+`, Style: "blue"},
+	}})
+	actual := theme.Colorize("error")
+	expected := "\033[31merror\033[39m"
+	if actual != expected {
+		t.Errorf("\nExpected: %v\n  Actual: %v", expected, actual)
+	}
+}
+
+func TestThemeNonAsciiText(t *testing.T) {
+	theme := MustCompileTheme(ThemeDefinition{Rules: []ThemeRule{
+		{Pattern: `\d+`, Style: "red"},
+	}})
+	actual := theme.Colorize("Привет 42 мир")
+	expected := "Привет \033[31m42\033[39m мир"
+	if actual != expected {
+		t.Errorf("\nExpected: %v\n  Actual: %v", expected, actual)
+	}
+}
+
+func TestThemeReportsUnknownColor(t *testing.T) {
+	_, err := ParseTheme([]byte(`
+rules:
+  - pattern: 'x'
+    style: bisque
+`))
+	if err == nil || !strings.Contains(err.Error(), "unknown color 'bisque'") {
+		t.Errorf("Expected unknown color error, got %v", err)
+	}
+}
+
+func TestThemeReportsInvalidPattern(t *testing.T) {
+	_, err := ParseTheme([]byte(`
+rules:
+  - pattern: '(x'
+    style: red
+`))
+	if err == nil {
+		t.Errorf("Expected invalid pattern error")
+	}
+}
+
+func TestThemeReportsUnknownField(t *testing.T) {
+	_, err := ParseTheme([]byte(`
+rules:
+  - pattern: 'x'
+    colour: red
+`))
+	if err == nil {
+		t.Errorf("Expected unknown field error")
+	}
+}
+
+func TestValidateStyle(t *testing.T) {
+	valid := []string{"", "reset", "red", "red+bu:blue+h", ":yellow", "RosyBrown:DodgerBlue", "#FF8800", "reset:blue"}
+	for _, style := range valid {
+		if err := ValidateStyle(style); err != nil {
+			t.Errorf("'%s': unexpected error %v", style, err)
+		}
+	}
+	invalid := []string{"bisque", "red+x", "#FF88", "#GG8800", "red:blue:green", ":nocolor"}
+	for _, style := range invalid {
+		if err := ValidateStyle(style); err == nil {
+			t.Errorf("'%s': expected error", style)
+		}
+	}
+}
+
+func TestStyleAttrLetters(t *testing.T) {
+	for _, letter := range styleAttrLetters {
+		style := "red+" + string(letter)
+		if err := ValidateStyle(style); err != nil {
+			t.Errorf("'%s': unexpected error %v", style, err)
+		}
+		if parsed := ParseStyle(style); parsed.Attrs == 0 && letter != 'h' {
+			t.Errorf("'%s': attribute is not applied", style)
+		}
+	}
+	for _, a := range styleAttrFlags {
+		if !strings.ContainsRune(styleAttrLetters, a.letter) {
+			t.Errorf("Attribute '%c' is parsed, but not accepted by validation", a.letter)
+		}
+	}
+}
+
+func TestLoadBuiltinTheme(t *testing.T) {
+	theme, err := LoadTheme("Light")
+	if err != nil || theme != LightTheme {
+		t.Errorf("Expected built-in light theme, got %v", err)
+	}
+	if _, err = LoadTheme("/nonexistent/theme.yaml"); err == nil {
+		t.Errorf("Expected error for missing theme file")
+	}
+}
+
+func TestHighlightRules(t *testing.T) {
+	customer, err := ParseHighlightRule(`cust-\d+=red+b`)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	order, err := ParseHighlightRule(`order=\d+=#FF8800`)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if order.Pattern != `order=\d+` || order.Style != "#FF8800" {
+		t.Errorf("Unexpected rule %+v", order)
+	}
+	theme := MustCompileTheme(ThemeDefinition{Rules: []ThemeRule{customer, order}})
+	actual := theme.Colorize("cust-1 order=15, cust-2 order=16 CUST-3")
+	expected := "\033[31;1mcust-1\033[39;22m \033[38;2;255;136;0morder=15\033[39m, " +
+		"\033[31;1mcust-2\033[39;22m \033[38;2;255;136;0morder=16\033[39m CUST-3"
+	if actual != expected {
+		t.Errorf("\nExpected: %v\n  Actual: %v", expected, actual)
+	}
+}
+
+func TestHighlightsTakePrecedenceOverTheme(t *testing.T) {
+	theme := MustCompileTheme(ThemeDefinition{Rules: []ThemeRule{
+		{Pattern: `order-\d+`, Style: "red+b"},
+		{Pattern: `\d+`, Style: "yellow"},
+	}})
+	rule, err := ParseHighlightRule(`order-15=green`)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	highlights := MustCompileTheme(ThemeDefinition{Rules: []ThemeRule{rule}})
+	// precedence depends on the priorities, not on the order in which the themes are applied
+	for _, order := range [][]*Theme{{theme, highlights}, {highlights, theme}} {
+		text := NewStyledText("order-15 and order-16")
+		for _, th := range order {
+			priority := PriorityTheme
+			if th == highlights {
+				priority = PriorityHighlight
+			}
+			th.Apply(text, priority)
+		}
+		actual := text.Render()
+		expected := "\033[32;1morder-15\033[39;22m and \033[31;1morder-16\033[39;22m"
+		if actual != expected {
+			t.Errorf("\nExpected: %q\n  Actual: %q", expected, actual)
+		}
+	}
+}
+
+func TestInvalidHighlightRule(t *testing.T) {
+	for _, spec := range []string{"nostyle", "=red", "pattern="} {
+		if _, err := ParseHighlightRule(spec); err == nil {
+			t.Errorf("'%s': expected error", spec)
+		}
+	}
+}
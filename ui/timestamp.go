@@ -0,0 +1,126 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// TimeFormatDelta displays time passed since the previous event
+	TimeFormatDelta = "delta"
+	// TimeFormatAge displays how long ago the event happened
+	TimeFormatAge = "age"
+)
+
+var strftimeLayouts = map[byte]string{
+	'Y': "2006",
+	'y': "06",
+	'm': "01",
+	'b': "Jan",
+	'h': "Jan",
+	'B': "January",
+	'd': "02",
+	'e': "_2",
+	'j': "002",
+	'a': "Mon",
+	'A': "Monday",
+	'H': "15",
+	'I': "03",
+	'M': "04",
+	'S': "05",
+	'L': "000",
+	'f': "000000",
+	'N': "000000000",
+	'p': "PM",
+	'Z': "MST",
+	'z': "-0700",
+	'T': "15:04:05",
+	'F': "2006-01-02",
+	'D': "01/02/06",
+	'R': "15:04",
+	'%': "%",
+}
+
+// StrftimeToLayout converts strftime-style format, like "%Y-%m-%d %H:%M:%S.%L", into Go time layout.
+// Strings without '%' are considered to be Go layouts already and are returned unchanged.
+// Unsupported directives are kept as is
+func StrftimeToLayout(format string) string {
+	if !strings.Contains(format, "%") {
+		return format
+	}
+	var sb strings.Builder
+	for i := 0; i < len(format); i++ {
+		if format[i] == '%' && i+1 < len(format) {
+			if layout, ok := strftimeLayouts[format[i+1]]; ok {
+				sb.WriteString(layout)
+				i++
+				continue
+			}
+		}
+		sb.WriteByte(format[i])
+	}
+	return sb.String()
+}
+
+// FormatDuration formats duration with millisecond precision, e.g. "12.345s", "3m05.000s" or "1h02m03.004s"
+func FormatDuration(d time.Duration) string {
+	sign := ""
+	if d < 0 {
+		sign = "-"
+		d = -d
+	}
+	d = d.Round(time.Millisecond)
+	seconds := float64(d%time.Minute) / float64(time.Second)
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%s%.3fs", sign, seconds)
+	case d < time.Hour:
+		return fmt.Sprintf("%s%dm%06.3fs", sign, int(d/time.Minute), seconds)
+	default:
+		return fmt.Sprintf("%s%dh%02dm%06.3fs", sign, int(d/time.Hour), int(d%time.Hour/time.Minute), seconds)
+	}
+}
+
+// TimestampFormatter formats event timestamps either with a time layout or relative to previous event or current time
+type TimestampFormatter struct {
+	layout   string
+	location *time.Location
+	previous *time.Time
+	now      func() time.Time
+}
+
+// NewTimestampFormatter creates a formatter for the format, which is either TimeFormatDelta, TimeFormatAge,
+// Go time layout or strftime-style format. Absolute timestamps are displayed in location
+func NewTimestampFormatter(format string, location *time.Location) *TimestampFormatter {
+	layout := format
+	if format != TimeFormatDelta && format != TimeFormatAge {
+		layout = StrftimeToLayout(format)
+	}
+	return &TimestampFormatter{
+		layout:   layout,
+		location: location,
+		now:      time.Now,
+	}
+}
+
+// Format formats the event timestamp. In delta mode every call remembers the timestamp to calculate the next delta,
+// so it is called only for the lines which are printed
+func (f *TimestampFormatter) Format(timestamp time.Time) string {
+	switch f.layout {
+	case TimeFormatDelta:
+		var delta time.Duration
+		if f.previous != nil {
+			delta = timestamp.Sub(*f.previous)
+		}
+		f.previous = &timestamp
+		if delta < 0 {
+			return FormatDuration(delta)
+		}
+		return "+" + FormatDuration(delta)
+	case TimeFormatAge:
+		return FormatDuration(f.now().Sub(timestamp)) + " ago"
+	default:
+		return timestamp.In(f.location).Format(f.layout)
+	}
+}
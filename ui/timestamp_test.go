@@ -0,0 +1,73 @@
+package ui
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStrftimeToLayout(t *testing.T) {
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{"%Y-%m-%d %H:%M:%S.%L", "2006-01-02 15:04:05.000"},
+		{"%T %Z", "15:04:05 MST"},
+		{"%F %I:%M %p", "2006-01-02 03:04 PM"},
+		{"100%% %H %Q", "100% 15 %Q"},
+		{"15:04:05.000", "15:04:05.000"},
+	}
+	for _, test := range tests {
+		actual := StrftimeToLayout(test.format)
+		if actual != test.expected {
+			t.Errorf("'%s'\nExpected: %v\n  Actual: %v", test.format, test.expected, actual)
+		}
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		duration time.Duration
+		expected string
+	}{
+		{0, "0.000s"},
+		{12345 * time.Millisecond, "12.345s"},
+		{3*time.Minute + 5*time.Second, "3m05.000s"},
+		{time.Hour + 2*time.Minute + 3004*time.Millisecond, "1h02m03.004s"},
+		{-1500 * time.Millisecond, "-1.500s"},
+	}
+	for _, test := range tests {
+		actual := FormatDuration(test.duration)
+		if actual != test.expected {
+			t.Errorf("%v\nExpected: %v\n  Actual: %v", test.duration, test.expected, actual)
+		}
+	}
+}
+
+func TestTimestampFormatterLayoutAndLocation(t *testing.T) {
+	formatter := NewTimestampFormatter("%H:%M:%S.%L", time.UTC)
+	tm := time.Date(2026, 10, 16, 10, 0, 1, 250000000, time.FixedZone("TEST", 3600))
+	actual := formatter.Format(tm)
+	if actual != "09:00:01.250" {
+		t.Errorf("Expected: 09:00:01.250\n  Actual: %v", actual)
+	}
+}
+
+func TestTimestampFormatterDelta(t *testing.T) {
+	formatter := NewTimestampFormatter(TimeFormatDelta, time.UTC)
+	tm := time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)
+	first := formatter.Format(tm)
+	second := formatter.Format(tm.Add(12345 * time.Millisecond))
+	if first != "+0.000s" || second != "+12.345s" {
+		t.Errorf("Expected: +0.000s, +12.345s\n  Actual: %v, %v", first, second)
+	}
+}
+
+func TestTimestampFormatterAge(t *testing.T) {
+	now := time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)
+	formatter := NewTimestampFormatter(TimeFormatAge, time.UTC)
+	formatter.now = func() time.Time { return now }
+	actual := formatter.Format(now.Add(-90 * time.Second))
+	if actual != "1m30.000s ago" {
+		t.Errorf("Expected: 1m30.000s ago\n  Actual: %v", actual)
+	}
+}